@@ -0,0 +1,427 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNotInstalled = errors.New("git is not installed")
+	ErrNotRepo      = errors.New("not a git repository")
+)
+
+// FileStatus status of the file, the greater status is preferred when aggregating to directories
+type FileStatus int
+
+const (
+	Unmodified FileStatus = iota
+	Ignored
+	Untracked
+	Staged
+	Modified
+	Conflicted
+)
+
+// String return the symbol of the status
+func (s FileStatus) String() string {
+	switch s {
+	case Ignored:
+		return "!"
+	case Untracked:
+		return "?"
+	case Staged:
+		return "S"
+	case Modified:
+		return "M"
+	case Conflicted:
+		return "U"
+	}
+	return ""
+}
+
+// Status status of the working tree
+type Status struct {
+	Root   string
+	Branch string
+	Ahead  int
+	Behind int
+	// listed directory and its path from the root
+	dir    string
+	prefix string
+	// paths from the root
+	files map[string]FileStatus
+	dirs  map[string]FileStatus
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// GetStatus get status of the repository which has the dir
+func GetStatus(ctx context.Context, dir string) (*Status, error) {
+	out, err := run(ctx, dir, "rev-parse", "--is-inside-work-tree", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return nil, ErrNotRepo
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) < 2 || lines[0] != "true" {
+		return nil, ErrNotRepo
+	}
+
+	s := &Status{
+		Root:  lines[1],
+		dir:   dir,
+		files: make(map[string]FileStatus),
+		dirs:  make(map[string]FileStatus),
+	}
+	if len(lines) > 2 {
+		s.prefix = strings.TrimSuffix(lines[2], "/")
+	}
+
+	out, err = run(ctx, s.Root, "status", "--porcelain=v2", "--branch", "--ignored", "-z")
+	if err != nil {
+		return nil, err
+	}
+	s.parse(out)
+	return s, nil
+}
+
+func (s *Status) parse(out []byte) {
+	records := strings.Split(string(out), "\x00")
+	for i := 0; i < len(records); i++ {
+		r := records[i]
+		if r == "" {
+			continue
+		}
+
+		switch r[0] {
+		case '#':
+			fields := strings.Fields(r)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.head":
+				s.Branch = fields[2]
+			case "branch.ab":
+				fmt.Sscanf(strings.Join(fields[2:], " "), "+%d -%d", &s.Ahead, &s.Behind)
+			}
+
+		// changed
+		case '1':
+			if fields := strings.SplitN(r, " ", 9); len(fields) == 9 {
+				s.add(fields[8], changeStatus(fields[1]))
+			}
+
+		// renamed or copied, the original path follows
+		case '2':
+			if fields := strings.SplitN(r, " ", 10); len(fields) == 10 {
+				s.add(fields[9], changeStatus(fields[1]))
+			}
+			i++
+
+		// unmerged
+		case 'u':
+			if fields := strings.SplitN(r, " ", 11); len(fields) == 11 {
+				s.add(fields[10], Conflicted)
+			}
+
+		case '?':
+			s.add(r[2:], Untracked)
+
+		case '!':
+			s.add(r[2:], Ignored)
+		}
+	}
+}
+
+// changeStatus convert XY of the index and the working tree
+func changeStatus(xy string) FileStatus {
+	if len(xy) == 2 && xy[1] != '.' {
+		return Modified
+	}
+	return Staged
+}
+
+func (s *Status) add(path string, status FileStatus) {
+	// untracked or ignored directory ends with slash
+	path = strings.TrimSuffix(path, "/")
+	s.files[path] = status
+
+	// ignored files don't change the parents
+	if status == Ignored {
+		return
+	}
+	for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+		if s.dirs[dir] < status {
+			s.dirs[dir] = status
+		}
+	}
+}
+
+// Get get the status of the file or the directory in the listed directory
+func (s *Status) Get(path string) FileStatus {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return Unmodified
+	}
+	rel = filepath.Join(s.prefix, rel)
+
+	if status, ok := s.files[rel]; ok {
+		return status
+	}
+	if status, ok := s.dirs[rel]; ok {
+		return status
+	}
+
+	// files in untracked or ignored directory
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if status, ok := s.files[dir]; ok {
+			return status
+		}
+	}
+	return Unmodified
+}
+
+// Commit commit in the log
+type Commit struct {
+	Hash    string
+	Date    string
+	Author  string
+	Subject string
+	// path of the file at the commit from the top of the repository, it changes when the file is renamed
+	Path string
+}
+
+// runFile run git in the directory of the file with the file name
+func runFile(path string, args ...string) ([]byte, error) {
+	args = append(args, "--", filepath.Base(path))
+	return run(context.Background(), filepath.Dir(path), args...)
+}
+
+// Stage add the file to the index
+func Stage(path string) error {
+	_, err := runFile(path, "add")
+	return err
+}
+
+// Unstage reset the file in the index
+func Unstage(path string) error {
+	_, err := runFile(path, "reset", "-q")
+	return err
+}
+
+// Discard discard changes of the file in the working tree
+func Discard(path string) error {
+	_, err := runFile(path, "checkout")
+	return err
+}
+
+// Diff diff the working tree and HEAD
+func Diff(ctx context.Context, path string) (string, error) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	out, err := run(ctx, dir, "diff", "--no-color", "HEAD", "--", name)
+	if err != nil {
+		// no commit yet
+		out, err = run(ctx, dir, "diff", "--no-color", "--", name)
+	}
+	return string(out), err
+}
+
+// Log get commits which changed the file
+func Log(path string) ([]Commit, error) {
+	out, err := runFile(path, "log", "-z", "--follow", "--name-only", "--date=short", "--format=%h%x09%ad%x09%an%x09%s")
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(string(out)), nil
+}
+
+// parseLog parse the output of git log -z --name-only,
+// each commit is followed by the path of the file at the commit
+func parseLog(out string) []Commit {
+	var commits []Commit
+	for _, field := range strings.Split(out, "\x00") {
+		if strings.HasPrefix(field, "\n") {
+			if len(commits) > 0 {
+				commits[len(commits)-1].Path = field[1:]
+			}
+			continue
+		}
+
+		fields := strings.SplitN(field, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Date:    fields[1],
+			Author:  fields[2],
+			Subject: fields[3],
+		})
+	}
+	return commits
+}
+
+// Show get content of the file at the commit, the file may have been renamed since the commit
+func Show(c Commit, path string) ([]byte, error) {
+	rev := c.Hash + ":./" + filepath.Base(path)
+	if c.Path != "" {
+		rev = c.Hash + ":" + c.Path
+	}
+	return run(context.Background(), filepath.Dir(path), "show", rev)
+}
+
+// CheckIgnore get names in the dir which are ignored by .gitignore,
+// .git/info/exclude and the global excludes file
+func CheckIgnore(ctx context.Context, dir string, names []string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "check-ignore", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(names, "\x00") + "\x00")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		// none of the names are ignored
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		if strings.Contains(stderr.String(), "not a git repository") {
+			return nil, ErrNotRepo
+		}
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var ignored []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			ignored = append(ignored, name)
+		}
+	}
+	return ignored, nil
+}
+
+// IgnoreChecker check many directories with one git check-ignore process,
+// paths are relative to the dir which the checker is started in
+type IgnoreChecker struct {
+	cmd    *exec.Cmd
+	in     io.WriteCloser
+	out    *bufio.Reader
+	stderr bytes.Buffer
+	err    error
+}
+
+// NewIgnoreChecker start git check-ignore in the dir
+func NewIgnoreChecker(ctx context.Context, dir string) (*IgnoreChecker, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNotInstalled
+	}
+
+	c := &IgnoreChecker{}
+	// print a result for every path, and flush it without waiting for the end of the input
+	c.cmd = exec.CommandContext(ctx, "git", "-C", dir, "check-ignore", "-z", "-v", "-n", "--stdin")
+	c.cmd.Env = append(os.Environ(), "GIT_FLUSH=1")
+	c.cmd.Stderr = &c.stderr
+
+	in, err := c.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	c.in = in
+	c.out = bufio.NewReader(out)
+	return c, nil
+}
+
+// Ignored get paths which are ignored, the checker can't be used after it fails
+func (c *IgnoreChecker) Ignored(paths []string) ([]string, error) {
+	if c.err != nil || len(paths) == 0 {
+		return nil, c.err
+	}
+
+	// write in background, git blocks on writing results if they are not read
+	written := make(chan error, 1)
+	go func() {
+		var err error
+		for _, p := range paths {
+			if _, err = io.WriteString(c.in, p+"\x00"); err != nil {
+				break
+			}
+		}
+		written <- err
+	}()
+
+	// the result of each path is: source, line number, pattern and path
+	var ignored []string
+	for range paths {
+		var fields [4]string
+		for i := range fields {
+			field, err := c.out.ReadString(0)
+			if err != nil {
+				c.fail()
+				return nil, c.err
+			}
+			fields[i] = strings.TrimSuffix(field, "\x00")
+		}
+		// negated patterns match but don't ignore
+		if fields[0] != "" && !strings.HasPrefix(fields[2], "!") {
+			ignored = append(ignored, fields[3])
+		}
+	}
+
+	if err := <-written; err != nil {
+		c.fail()
+		return nil, c.err
+	}
+	return ignored, nil
+}
+
+// fail stop the process and keep the reason
+func (c *IgnoreChecker) fail() {
+	c.in.Close()
+	c.cmd.Wait()
+	if strings.Contains(c.stderr.String(), "not a git repository") {
+		c.err = ErrNotRepo
+	} else {
+		c.err = fmt.Errorf("git check-ignore: %s", strings.TrimSpace(c.stderr.String()))
+	}
+}
+
+// Close stop the process, the exit status is meaningless because it depends on the results
+func (c *IgnoreChecker) Close() {
+	if c.err != nil {
+		return
+	}
+	c.in.Close()
+	c.cmd.Wait()
+}
@@ -0,0 +1,291 @@
+package git
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testRepo create a repository with the files, and remove it with the returned func
+func testRepo(t *testing.T, files map[string]string) (string, func()) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+var ignoreFiles = map[string]string{
+	".gitignore":     "*.log\nbuild/\n!keep.log\n",
+	"a.log":          "",
+	"b.txt":          "",
+	"keep.log":       "",
+	"build/out":      "",
+	"src/c.log":      "",
+	"src/d.go":       "",
+	"src/deep/e.log": "",
+}
+
+func TestCheckIgnore(t *testing.T) {
+	dir, remove := testRepo(t, ignoreFiles)
+	defer remove()
+
+	got, err := CheckIgnore(context.Background(), dir, []string{"a.log", "b.txt", "keep.log", "build", "src"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"a.log", "build"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckIgnore() = %v, want %v", got, want)
+	}
+}
+
+func TestIgnoreChecker(t *testing.T) {
+	dir, remove := testRepo(t, ignoreFiles)
+	defer remove()
+
+	c, err := NewIgnoreChecker(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the same process answers every directory
+	tests := []struct {
+		paths []string
+		want  []string
+	}{
+		{[]string{"a.log", "b.txt", "keep.log", "build", "src"}, []string{"a.log", "build"}},
+		{[]string{"src/c.log", "src/d.go", "src/deep"}, []string{"src/c.log"}},
+		{[]string{"src/deep/e.log"}, []string{"src/deep/e.log"}},
+		{[]string{"b.txt"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := c.Ignored(tt.paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Ignored(%v) = %v, want %v", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreCheckerNotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := NewIgnoreChecker(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Ignored([]string{"a"}); err != ErrNotRepo {
+		t.Errorf("err = %v, want %v", err, ErrNotRepo)
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	out := "abc1234\t2021-03-01\tauthor\tsecond\x00\nsrc/b.txt\x00" +
+		"def5678\t2021-02-01\tauthor\tfirst\twith tab\x00\na.txt\x00"
+	want := []Commit{
+		{Hash: "abc1234", Date: "2021-03-01", Author: "author", Subject: "second", Path: "src/b.txt"},
+		{Hash: "def5678", Date: "2021-02-01", Author: "author", Subject: "first\twith tab", Path: "a.txt"},
+	}
+	if got := parseLog(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLog() = %+v, want %+v", got, want)
+	}
+}
+
+func TestShowRenamed(t *testing.T) {
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\n"
+	dir, remove := testRepo(t, map[string]string{"a.txt": content})
+	defer remove()
+
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=ff", "-c", "user.email=ff@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	git("mv", "a.txt", "src/b.txt")
+	git("commit", "-q", "-m", "rename")
+
+	path := filepath.Join(dir, "src", "b.txt")
+	commits, err := Log(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("len(commits) = %d, want 2", len(commits))
+	}
+	if got := commits[1].Path; got != "a.txt" {
+		t.Errorf("path at the first commit = %q, want %q", got, "a.txt")
+	}
+
+	// the file at the commit before the rename
+	b, err := Show(commits[1], path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("Show() = %q, want %q", b, content)
+	}
+}
+
+func TestStatusParse(t *testing.T) {
+	out := "# branch.oid abc1234\x00" +
+		"# branch.head main\x00" +
+		"# branch.upstream origin/main\x00" +
+		"# branch.ab +2 -1\x00" +
+		"1 .M N... 100644 100644 100644 abc abc src/a.go\x00" +
+		"1 A. N... 000000 100644 100644 000 abc src/deep/new file.go\x00" +
+		"2 R. N... 100644 100644 100644 abc abc R100 b.go\x00old.go\x00" +
+		"u UU N... 100644 100644 100644 100644 abc abc abc conflict.go\x00" +
+		"? untracked/\x00" +
+		"! build/\x00"
+
+	s := &Status{files: make(map[string]FileStatus), dirs: make(map[string]FileStatus)}
+	s.parse([]byte(out))
+
+	if s.Branch != "main" || s.Ahead != 2 || s.Behind != 1 {
+		t.Errorf("branch = %q +%d -%d, want main +2 -1", s.Branch, s.Ahead, s.Behind)
+	}
+	wantFiles := map[string]FileStatus{
+		"src/a.go":             Modified,
+		"src/deep/new file.go": Staged,
+		"b.go":                 Staged,
+		"conflict.go":          Conflicted,
+		"untracked":            Untracked,
+		"build":                Ignored,
+	}
+	if !reflect.DeepEqual(s.files, wantFiles) {
+		t.Errorf("files = %v, want %v", s.files, wantFiles)
+	}
+	// the greater status of the children
+	wantDirs := map[string]FileStatus{
+		"src":      Modified,
+		"src/deep": Staged,
+	}
+	if !reflect.DeepEqual(s.dirs, wantDirs) {
+		t.Errorf("dirs = %v, want %v", s.dirs, wantDirs)
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	dir, remove := testRepo(t, map[string]string{
+		".gitignore":   "*.log\n",
+		"a.txt":        "a",
+		"src/b.txt":    "b",
+		"src/c.txt":    "c",
+		"debug.log":    "",
+		"new/file.txt": "",
+	})
+	defer remove()
+
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=ff", "-c", "user.email=ff@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+	git("add", ".gitignore", "a.txt", "src")
+	git("commit", "-q", "-m", "first")
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "b.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("mv", "src/c.txt", "src/d.txt")
+
+	s, err := GetStatus(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Root != root {
+		t.Errorf("Root = %q, want %q", s.Root, root)
+	}
+
+	tests := []struct {
+		path string
+		want FileStatus
+	}{
+		{"src/b.txt", Modified},
+		{"src/d.txt", Staged},
+		{"a.txt", Unmodified},
+		{"src", Modified},
+		{"debug.log", Ignored},
+		{"new", Untracked},
+		{"new/file.txt", Untracked},
+	}
+	for _, tt := range tests {
+		if got := s.Get(filepath.Join(dir, tt.path)); got != tt.want {
+			t.Errorf("Get(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// paths are relative to the listed subdirectory
+	if s, err = GetStatus(context.Background(), filepath.Join(dir, "src")); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Get(filepath.Join(dir, "src", "b.txt")); got != Modified {
+		t.Errorf("Get(src/b.txt) in src = %q, want %q", got, Modified)
+	}
+	if got := s.Get(filepath.Join(dir, "a.txt")); got != Unmodified {
+		t.Errorf("Get(a.txt) in src = %q, want %q", got, Unmodified)
+	}
+}
+
+func TestGetStatusNotRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := GetStatus(context.Background(), dir); err != ErrNotRepo {
+		t.Errorf("err = %v, want %v", err, ErrNotRepo)
+	}
+}
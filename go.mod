@@ -10,7 +10,8 @@ require (
 	github.com/mattn/go-sqlite3 v1.11.0
 	github.com/otiai10/copy v1.0.2
 	github.com/rivo/tview v0.0.0-20210312174852-ae9464cc3598
-	golang.org/x/sys v0.0.0-20210316092937-0b90fd5c4c48 // indirect
+	golang.org/x/image v0.0.0-20210220032944-ac19c3e999fb
+	golang.org/x/sys v0.0.0-20210316092937-0b90fd5c4c48
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/djherbis/times.v1 v1.2.0
 	gopkg.in/yaml.v2 v2.2.7
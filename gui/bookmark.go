@@ -2,31 +2,122 @@ package gui
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
 	"github.com/skanehira/ff/system"
 )
 
+// key to go to the bookmark with the shortcut
+const bookmarkPrefixKey = '`'
+
+const (
+	bookmarkSortUsage = "usage"
+	bookmarkSortName  = "name"
+)
+
 type DBLogger struct{}
 
 func (d DBLogger) Print(v ...interface{}) {
 	log.Print(v...)
 }
 
+// Bookmark bookmarked directory, Name is the path of the directory
 type Bookmark struct {
-	ID   int
-	Name string
+	ID       int
+	Name     string
+	Alias    string
+	Tags     []string
+	Shortcut string
+	Created  time.Time
+	LastUsed time.Time
+	Visits   int
+}
+
+// Label alias of the bookmark, or the path if it has no alias
+func (b *Bookmark) Label() string {
+	if b.Alias != "" {
+		return b.Alias
+	}
+	return b.Name
+}
+
+func (b *Bookmark) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Exists return true if the directory still exists, s3 paths are not checked
+func (b *Bookmark) Exists() bool {
+	return s3.IsPath(b.Name) || system.IsExist(b.Name)
+}
+
+// parseTags parse comma separated tags
+func parseTags(text string) []string {
+	var tags []string
+	for _, t := range strings.Split(text, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(dateFmt)
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// shortPath replace the home directory with ~
+func shortPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || home == "/" {
+		return path
+	}
+	if path == home || strings.HasPrefix(path, home+"/") {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+	return path
 }
 
 type BookmarkStore struct {
 	db *sql.DB
 }
 
+// columns which have been added to the first schema
+var bookmarkColumns = []struct {
+	name string
+	def  string
+}{
+	{"alias", "varchar(255) NOT NULL DEFAULT ''"},
+	{"tags", "varchar(255) NOT NULL DEFAULT ''"},
+	{"shortcut", "varchar(1) NOT NULL DEFAULT ''"},
+	{"created_at", "integer NOT NULL DEFAULT 0"},
+	{"last_used_at", "integer NOT NULL DEFAULT 0"},
+	{"visits", "integer NOT NULL DEFAULT 0"},
+}
+
 func NewBookmarkStore(file string) (*BookmarkStore, error) {
 	file = os.ExpandEnv(file)
 	// if db file is not exist, use in memory db
@@ -50,9 +141,47 @@ CREATE TABLE IF NOT EXISTS "bookmarks" ("id" integer, "name" varchar(255) , PRIM
 		return nil, err
 	}
 
+	if err := migrateBookmarks(db); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
 	return &BookmarkStore{db: db}, nil
 }
 
+// migrateBookmarks add columns which don't exist in the database
+func migrateBookmarks(db *sql.DB) error {
+	rows, err := db.Query(`PRAGMA table_info("bookmarks")`)
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notnull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+
+	for _, c := range bookmarkColumns {
+		if columns[c.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE "bookmarks" ADD COLUMN "%s" %s`, c.name, c.def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *BookmarkStore) HasBookmark(name string) bool {
 	var count int
 
@@ -72,7 +201,8 @@ func (b *BookmarkStore) HasBookmark(name string) bool {
 
 func (b *BookmarkStore) Save(bookmark Bookmark) error {
 	if !b.HasBookmark(bookmark.Name) {
-		_, err := b.db.Exec("insert into bookmarks (name) values (?)", bookmark.Name)
+		_, err := b.db.Exec("insert into bookmarks (name, alias, tags, shortcut, created_at) values (?, ?, ?, ?, ?)",
+			bookmark.Name, bookmark.Alias, strings.Join(bookmark.Tags, ","), bookmark.Shortcut, time.Now().Unix())
 		if err != nil {
 			log.Println(err)
 			return err
@@ -81,10 +211,31 @@ func (b *BookmarkStore) Save(bookmark Bookmark) error {
 	return nil
 }
 
+// Update update the alias, the tags and the shortcut of the bookmark
+func (b *BookmarkStore) Update(bookmark Bookmark) error {
+	_, err := b.db.Exec("update bookmarks set alias = ?, tags = ?, shortcut = ? where id = ?",
+		bookmark.Alias, strings.Join(bookmark.Tags, ","), bookmark.Shortcut, bookmark.ID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
+// Visit count the visit of the bookmark
+func (b *BookmarkStore) Visit(id int) error {
+	_, err := b.db.Exec("update bookmarks set visits = visits + 1, last_used_at = ? where id = ?", time.Now().Unix(), id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
 func (b *BookmarkStore) Load() ([]Bookmark, error) {
 	var bookmarks []Bookmark
 
-	rows, err := b.db.Query("select * from bookmarks")
+	rows, err := b.db.Query("select id, name, alias, tags, shortcut, created_at, last_used_at, visits from bookmarks")
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -92,15 +243,24 @@ func (b *BookmarkStore) Load() ([]Bookmark, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var id int
-		var name string
-		if err := rows.Scan(&id, &name); err != nil {
+		var (
+			id, visits             int
+			name, alias, tags, key string
+			createdAt, lastUsedAt  int64
+		)
+		if err := rows.Scan(&id, &name, &alias, &tags, &key, &createdAt, &lastUsedAt, &visits); err != nil {
 			log.Println(err)
 			return nil, err
 		}
 		bookmarks = append(bookmarks, Bookmark{
-			ID:   id,
-			Name: name,
+			ID:       id,
+			Name:     name,
+			Alias:    alias,
+			Tags:     parseTags(tags),
+			Shortcut: key,
+			Created:  unixTime(createdAt),
+			LastUsed: unixTime(lastUsedAt),
+			Visits:   visits,
 		})
 	}
 
@@ -119,7 +279,10 @@ func (b *BookmarkStore) Delete(id int) error {
 type Bookmarks struct {
 	store            *BookmarkStore
 	entries          []*Bookmark
+	filtered         []*Bookmark // entries which are shown in the table
 	searchWord       string
+	tag              string
+	sortBy           string
 	enableIgnorecase bool
 	*tview.Table
 }
@@ -140,8 +303,14 @@ func NewBookmark(config Config) (*Bookmarks, error) {
 		return nil, err
 	}
 
+	sortBy := config.Bookmark.Sort
+	if sortBy != bookmarkSortName {
+		sortBy = bookmarkSortUsage
+	}
+
 	return &Bookmarks{
 		store:            store,
+		sortBy:           sortBy,
 		enableIgnorecase: config.IgnoreCase,
 		Table:            table,
 	}, nil
@@ -182,11 +351,44 @@ func (b *Bookmarks) Update() error {
 	return b.UpdateView()
 }
 
+// sortEntries sort by visits and last used time, or by alias and path
+func (b *Bookmarks) sortEntries() {
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		x, y := b.entries[i], b.entries[j]
+		if b.sortBy == bookmarkSortUsage {
+			if x.Visits != y.Visits {
+				return x.Visits > y.Visits
+			}
+			if !x.LastUsed.Equal(y.LastUsed) {
+				return x.LastUsed.After(y.LastUsed)
+			}
+		}
+		return strings.ToLower(x.Label()) < strings.ToLower(y.Label())
+	})
+}
+
+func (b *Bookmarks) matchEntry(e *Bookmark) bool {
+	if b.tag != "" && !e.HasTag(b.tag) {
+		return false
+	}
+	for _, text := range []string{e.Name, e.Alias, strings.Join(e.Tags, ",")} {
+		if matchName(text, b.searchWord, b.enableIgnorecase) {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *Bookmarks) UpdateView() error {
 	table := b.Clear()
 
 	headers := []string{
-		"Name",
+		"Key",
+		"Alias",
+		"Path",
+		"Tags",
+		"Visits",
+		"Last used",
 	}
 	for k, v := range headers {
 		table.SetCell(0, k, &tview.TableCell{
@@ -198,46 +400,78 @@ func (b *Bookmarks) UpdateView() error {
 		})
 	}
 
-	var entries []*Bookmark
+	b.sortEntries()
+	b.filtered = nil
 	for _, e := range b.entries {
-		var name, word string
-		if b.enableIgnorecase {
-			name = strings.ToLower(e.Name)
-			word = strings.ToLower(b.searchWord)
-		} else {
-			name = e.Name
-			word = b.searchWord
+		if b.matchEntry(e) {
+			b.filtered = append(b.filtered, e)
 		}
+	}
 
-		if strings.Index(name, word) == -1 {
-			continue
+	for i, e := range b.filtered {
+		color := tcell.ColorWhite
+		path := shortPath(e.Name)
+		if !e.Exists() {
+			color = brokenLinkColor
+			path += " (missing)"
 		}
 
-		entries = append(entries, &Bookmark{Name: name})
+		table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(e.Shortcut)).SetTextColor(color))
+		table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(e.Alias)).SetTextColor(color))
+		table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(path)).SetTextColor(color))
+		table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(strings.Join(e.Tags, ","))).SetTextColor(color))
+		table.SetCell(i+1, 4, tview.NewTableCell(fmt.Sprint(e.Visits)).SetTextColor(color))
+		table.SetCell(i+1, 5, tview.NewTableCell(formatTime(e.LastUsed)).SetTextColor(color))
 	}
 
-	i := 1
-	for _, e := range entries {
-		table.SetCell(i, 0, tview.NewTableCell(e.Name))
-		i++
+	title := "bookmarks (by " + b.sortBy + ")"
+	if b.tag != "" {
+		title += " tag: " + b.tag
 	}
+	b.SetTitle(title)
 
 	return nil
 }
 
 func (b *Bookmarks) GetSelectEntry() *Bookmark {
 	row, _ := b.GetSelection()
-	if len(b.entries) == 0 {
+	if len(b.filtered) == 0 {
 		return nil
 	}
 	if row < 1 {
 		return nil
 	}
 
-	if row > len(b.entries) {
+	if row > len(b.filtered) {
 		return nil
 	}
-	return b.entries[row-1]
+	return b.filtered[row-1]
+}
+
+// FindShortcut find the bookmark which has the shortcut key
+func (b *Bookmarks) FindShortcut(key rune) (*Bookmark, error) {
+	entries, err := b.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Shortcut == string(key) {
+			e := e
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %c", ErrNoBookmark, key)
+}
+
+// Go change the directory to the bookmark, and count the visit
+func (b *Bookmarks) Go(gui *Gui, entry *Bookmark) error {
+	if !entry.Exists() {
+		return fmt.Errorf("%s: %s", ErrNotExistPath, entry.Name)
+	}
+	if err := gui.FileBrowser.ChangeDir(gui, gui.InputPath.GetText(), entry.Name); err != nil {
+		return err
+	}
+	return b.store.Visit(entry.ID)
 }
 
 func (e *Bookmarks) SearchBookmark(gui *Gui) {
@@ -264,6 +498,104 @@ func (e *Bookmarks) SearchBookmark(gui *Gui) {
 	}
 }
 
+// FilterTag show only bookmarks which have the tag, all bookmarks are shown if the tag is empty
+func (b *Bookmarks) FilterTag(gui *Gui) {
+	gui.Form(map[string]string{"tag": b.tag}, "filter", "filter by tag", "filter_bookmark", BookmarkPanel,
+		7, func(values map[string]string) error {
+			b.tag = strings.TrimSpace(values["tag"])
+			b.Select(1, 0)
+			return b.UpdateView()
+		})
+
+	gui.Pages.ShowPage("bookmark")
+}
+
+// ToggleSort sort by usage or by name
+func (b *Bookmarks) ToggleSort() {
+	if b.sortBy == bookmarkSortUsage {
+		b.sortBy = bookmarkSortName
+	} else {
+		b.sortBy = bookmarkSortUsage
+	}
+	b.UpdateView()
+}
+
+// EditBookmark edit the alias, the tags and the shortcut of the selected bookmark
+func (b *Bookmarks) EditBookmark(gui *Gui) {
+	entry := b.GetSelectEntry()
+	if entry == nil {
+		return
+	}
+
+	pageName := "edit_bookmark"
+	closeForm := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(BookmarkPanel)
+	}
+
+	form := tview.NewForm().
+		AddInputField("alias", entry.Alias, 0, nil, nil).
+		AddInputField("tags", strings.Join(entry.Tags, ","), 0, nil, nil).
+		AddInputField("shortcut", entry.Shortcut, 2, func(text string, last rune) bool {
+			return len([]rune(text)) <= 1
+		}, nil)
+
+	form.AddButton("save", func() {
+		edited := *entry
+		edited.Alias = strings.TrimSpace(form.GetFormItemByLabel("alias").(*tview.InputField).GetText())
+		edited.Tags = parseTags(form.GetFormItemByLabel("tags").(*tview.InputField).GetText())
+		edited.Shortcut = form.GetFormItemByLabel("shortcut").(*tview.InputField).GetText()
+
+		// a shortcut is used by only one bookmark
+		if edited.Shortcut != "" {
+			for _, e := range b.entries {
+				if e.ID != entry.ID && e.Shortcut == edited.Shortcut {
+					form.SetTitle(fmt.Sprintf("[red]%s: %s[-]", ErrShortcutUsed, tview.Escape(e.Label())))
+					return
+				}
+			}
+		}
+
+		if err := b.store.Update(edited); err != nil {
+			gui.Message(err.Error(), BookmarkPanel)
+			return
+		}
+		closeForm()
+		if err := b.Update(); err != nil {
+			gui.Message(err.Error(), BookmarkPanel)
+		}
+	}).
+		AddButton("cancel", closeForm).
+		SetCancelFunc(closeForm)
+
+	form.SetBorder(true).SetTitle("edit " + shortPath(entry.Name)).SetTitleAlign(tview.AlignLeft)
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(form, 0, 11), true).ShowPage("bookmark")
+}
+
+// PruneBookmarks delete bookmarks whose directories don't exist
+func (b *Bookmarks) PruneBookmarks(gui *Gui) {
+	var dead []*Bookmark
+	for _, e := range b.entries {
+		if !e.Exists() {
+			dead = append(dead, e)
+		}
+	}
+	if len(dead) == 0 {
+		gui.Message("no missing bookmarks", BookmarkPanel)
+		return
+	}
+
+	message := fmt.Sprintf("do you want to delete %d missing bookmarks?", len(dead))
+	gui.Confirm(message, "yes", BookmarkPanel, func() error {
+		for _, e := range dead {
+			if err := b.Delete(e.ID); err != nil {
+				return err
+			}
+		}
+		return b.Update()
+	})
+}
+
 func (b *Bookmarks) CloseBookmark(gui *Gui) {
 	gui.Pages.RemovePage("bookmark").ShowPage("main")
 	gui.FocusPanel(FileTablePanel)
@@ -281,6 +613,14 @@ func (b *Bookmarks) BookmarkKeybinding(gui *Gui) {
 			}
 			b.Delete(entry.ID)
 			b.Update()
+		case 'D':
+			b.PruneBookmarks(gui)
+		case 'e':
+			b.EditBookmark(gui)
+		case 's':
+			b.ToggleSort()
+		case 't':
+			b.FilterTag(gui)
 		case 'f', '/':
 			b.SearchBookmark(gui)
 		case 'a':
@@ -294,13 +634,13 @@ func (b *Bookmarks) BookmarkKeybinding(gui *Gui) {
 		case tcell.KeyF1:
 			gui.Help.UpdateView(BookmarkPanel)
 			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("bookmark")
-		case tcell.KeyCtrlG:
+		case tcell.KeyCtrlG, tcell.KeyEnter:
 			entry := gui.Bookmark.GetSelectEntry()
 			if entry == nil {
 				return event
 			}
 
-			if err := gui.FileBrowser.ChangeDir(gui, gui.InputPath.GetText(), entry.Name); err != nil {
+			if err := b.Go(gui, entry); err != nil {
 				gui.Message(err.Error(), BookmarkPanel)
 				return event
 			}
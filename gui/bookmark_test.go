@@ -0,0 +1,153 @@
+package gui
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMigrateBookmarks(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bookmark.db")
+
+	// the first schema which has only id and name
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`CREATE TABLE "bookmarks" ("id" integer, "name" varchar(255) , PRIMARY KEY ("id"))`,
+		`INSERT INTO bookmarks (id, name) VALUES (1, '/tmp'), (5, '/usr/local')`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	store, err := NewBookmarkStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Bookmark{{ID: 1, Name: "/tmp"}, {ID: 5, Name: "/usr/local"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load() = %+v, want %+v", got, want)
+	}
+
+	// new columns can be used after the migration
+	bookmark := Bookmark{ID: 1, Name: "/tmp", Alias: "temp", Tags: []string{"a", "b"}, Shortcut: "t"}
+	if err := store.Update(bookmark); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Visit(1); err != nil {
+		t.Fatal(err)
+	}
+	store.db.Close()
+
+	// the migrated database is opened again without changes
+	store, err = NewBookmarkStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.db.Close()
+	got, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len(Load()) = %d, want 2", len(got))
+	}
+	b := got[0]
+	if b.Alias != "temp" || !reflect.DeepEqual(b.Tags, []string{"a", "b"}) || b.Shortcut != "t" || b.Visits != 1 || b.LastUsed.IsZero() {
+		t.Errorf("bookmark = %+v, want the updated one", b)
+	}
+	if got[1].Name != "/usr/local" {
+		t.Errorf("name = %q, want %q", got[1].Name, "/usr/local")
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"work", []string{"work"}},
+		{"work,go", []string{"work", "go"}},
+		{" work , go ,, ", []string{"work", "go"}},
+		{"with space", []string{"with space"}},
+	}
+	for _, tt := range tests {
+		if got := parseTags(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTags(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBookmarksMatchEntry(t *testing.T) {
+	entry := &Bookmark{Name: "/home/user/src", Alias: "Source", Tags: []string{"work", "go"}}
+	tests := []struct {
+		name       string
+		tag        string
+		word       string
+		ignorecase bool
+		want       bool
+	}{
+		{"no filter", "", "", false, true},
+		{"path", "", "user/src", false, true},
+		{"alias", "", "Sour", false, true},
+		{"alias case", "", "sour", false, false},
+		{"ignorecase", "", "SOUR", true, true},
+		{"tag word", "", "work", false, true},
+		{"tag", "go", "", false, true},
+		{"tag prefix", "wor", "", false, false},
+		{"other tag", "home", "", false, false},
+		{"tag and word", "go", "src", false, true},
+		{"tag and other word", "go", "docs", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bookmarks{tag: tt.tag, searchWord: tt.word, enableIgnorecase: tt.ignorecase}
+			if got := b.matchEntry(entry); got != tt.want {
+				t.Errorf("matchEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookmarksSortEntries(t *testing.T) {
+	now := time.Now()
+	entries := []*Bookmark{
+		{Name: "/c"},
+		{Name: "/b", Visits: 2, LastUsed: now.Add(-time.Hour)},
+		{Name: "/z", Alias: "a", Visits: 1},
+		{Name: "/a", Visits: 2, LastUsed: now},
+	}
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{bookmarkSortUsage, []string{"/a", "/b", "/z", "/c"}},
+		// by the alias or the path
+		{bookmarkSortName, []string{"/a", "/b", "/c", "/z"}},
+	}
+	for _, tt := range tests {
+		b := &Bookmarks{sortBy: tt.sortBy, entries: append([]*Bookmark(nil), entries...)}
+		b.sortEntries()
+		var got []string
+		for _, e := range b.entries {
+			got = append(got, e.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sorted by %s = %q, want %q", tt.sortBy, got, tt.want)
+		}
+	}
+}
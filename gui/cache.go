@@ -0,0 +1,59 @@
+package gui
+
+import (
+	"container/list"
+	"sync"
+)
+
+type lruItem struct {
+	key   string
+	value interface{}
+}
+
+// lruCache least recently used cache which is safe for concurrent use
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	list  *list.List
+	items map[string]*list.Element
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		list:  list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get get the value and mark it as recently used
+func (c *lruCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.list.MoveToFront(e)
+	return e.Value.(*lruItem).value, true
+}
+
+// Add add the value, and remove the least recently used value if the cache is full
+func (c *lruCache) Add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		e.Value.(*lruItem).value = value
+		c.list.MoveToFront(e)
+		return
+	}
+
+	c.items[key] = c.list.PushFront(&lruItem{key: key, value: value})
+	for c.list.Len() > c.size {
+		e := c.list.Back()
+		c.list.Remove(e)
+		delete(c.items, e.Value.(*lruItem).key)
+	}
+}
@@ -0,0 +1,231 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+// permission bits which can be toggled with checkboxes
+var permBits = []struct {
+	label string
+	bit   os.FileMode
+}{
+	{"owner read", 0400},
+	{"owner write", 0200},
+	{"owner exec", 0100},
+	{"group read", 0040},
+	{"group write", 0020},
+	{"group exec", 0010},
+	{"other read", 0004},
+	{"other write", 0002},
+	{"other exec", 0001},
+}
+
+// formatMode format the mode as octal like chmod, e.g. 0755, 4755
+func formatMode(mode os.FileMode) string {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= 01000
+	}
+	return fmt.Sprintf("%04o", m)
+}
+
+// parseMode parse octal mode like chmod
+func parseMode(text string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(text, 8, 32)
+	if err != nil || m > 07777 {
+		return 0, ErrInvalidMode
+	}
+
+	mode := os.FileMode(m & 0777)
+	if m&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// localFiles selected or marked files which are not in s3
+func (gui *Gui) localFiles() ([]*File, error) {
+	files := gui.selectedFiles()
+	for _, f := range files {
+		if s3.IsPath(f.PathName) {
+			return nil, ErrNotSupported
+		}
+	}
+	return files, nil
+}
+
+func hasDir(files []*File) bool {
+	for _, f := range files {
+		if f.IsDir {
+			return true
+		}
+	}
+	return false
+}
+
+func filesTitle(action string, files []*File) string {
+	if len(files) == 1 {
+		return action + ": " + files[0].Name
+	}
+	return fmt.Sprintf("%s: %d marked files", action, len(files))
+}
+
+// showForm show the form as modal, the form is closed with esc or cancel
+func (gui *Gui) showForm(form *tview.Form, pageName string, width, height int) {
+	closeForm := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+	form.AddButton("cancel", closeForm).SetCancelFunc(closeForm)
+	form.SetTitleAlign(tview.AlignLeft).SetBorder(true)
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(form, width, height), true).ShowPage("main")
+}
+
+// ChmodForm edit the permission of the selected or marked files
+func (gui *Gui) ChmodForm() {
+	files, err := gui.localFiles()
+	if err != nil {
+		gui.Message(err.Error(), FileTablePanel)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	stat, err := os.Stat(files[0].PathName)
+	if err != nil {
+		log.Println(err)
+		gui.Message(err.Error(), FileTablePanel)
+		return
+	}
+	mode := stat.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+
+	pageName := "chmod"
+	form := tview.NewForm().SetItemPadding(0)
+	octal := tview.NewInputField().SetLabel("octal").SetFieldWidth(6).SetText(formatMode(mode))
+
+	// checkboxes and the octal input reflect each other
+	var checkboxes []*tview.Checkbox
+	for _, p := range permBits {
+		bit := p.bit
+		cb := tview.NewCheckbox().SetLabel(p.label).SetChecked(mode&bit != 0)
+		cb.SetChangedFunc(func(checked bool) {
+			m, err := parseMode(octal.GetText())
+			if err != nil {
+				m = mode
+			}
+			if checked {
+				m |= bit
+			} else {
+				m &^= bit
+			}
+			octal.SetText(formatMode(m))
+		})
+		checkboxes = append(checkboxes, cb)
+		form.AddFormItem(cb)
+	}
+	octal.SetChangedFunc(func(text string) {
+		m, err := parseMode(text)
+		if err != nil {
+			return
+		}
+		for i, cb := range checkboxes {
+			cb.SetChecked(m&permBits[i].bit != 0)
+		}
+	})
+	form.AddFormItem(octal)
+
+	var recursive bool
+	if hasDir(files) {
+		form.AddCheckbox("recursive", false, func(checked bool) {
+			recursive = checked
+		})
+	}
+
+	form.AddButton("apply", func() {
+		m, err := parseMode(octal.GetText())
+		if err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+			return
+		}
+
+		gui.Pages.RemovePage(pageName)
+		for _, f := range files {
+			if err := system.Chmod(f.PathName, m, recursive && f.IsDir); err != nil {
+				log.Println(err)
+				gui.Message(err.Error(), FileTablePanel)
+				gui.FileBrowser.UpdateView()
+				return
+			}
+		}
+		gui.FileBrowser.UpdateView()
+		gui.FocusPanel(FileTablePanel)
+	})
+
+	form.SetTitle(filesTitle("chmod", files))
+	gui.showForm(form, pageName, 40, form.GetFormItemCount()+6)
+}
+
+// ChownForm change the owner and the group of the selected or marked files
+func (gui *Gui) ChownForm() {
+	files, err := gui.localFiles()
+	if err != nil {
+		gui.Message(err.Error(), FileTablePanel)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	pageName := "chown"
+	form := tview.NewForm().
+		AddInputField("owner", files[0].Owner, 0, nil, nil).
+		AddInputField("group", files[0].Group, 0, nil, nil)
+
+	var recursive bool
+	if hasDir(files) {
+		form.AddCheckbox("recursive", false, func(checked bool) {
+			recursive = checked
+		})
+	}
+
+	form.AddButton("apply", func() {
+		owner := form.GetFormItemByLabel("owner").(*tview.InputField).GetText()
+		group := form.GetFormItemByLabel("group").(*tview.InputField).GetText()
+
+		gui.Pages.RemovePage(pageName)
+		for _, f := range files {
+			// changing owner usually needs privileges
+			if err := system.Chown(f.PathName, owner, group, recursive && f.IsDir); err != nil {
+				log.Println(err)
+				gui.Message(err.Error(), FileTablePanel)
+				gui.FileBrowser.UpdateView()
+				return
+			}
+		}
+		gui.FileBrowser.UpdateView()
+		gui.FocusPanel(FileTablePanel)
+	})
+
+	form.SetTitle(filesTitle("chown", files))
+	gui.showForm(form, pageName, 50, form.GetFormItemCount()*2+5)
+}
@@ -0,0 +1,602 @@
+package gui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+// size of the buffer to compare contents
+const compareBufferSize = 32 * 1024
+
+// cmpStatus result of the comparison of the entry
+type cmpStatus int
+
+const (
+	cmpSame cmpStatus = iota
+	cmpDiff
+	cmpLeftOnly
+	cmpRightOnly
+)
+
+func (s cmpStatus) String() string {
+	switch s {
+	case cmpDiff:
+		return "!"
+	case cmpLeftOnly:
+		return "<"
+	case cmpRightOnly:
+		return ">"
+	}
+	return "="
+}
+
+func (s cmpStatus) Color() tcell.Color {
+	switch s {
+	case cmpDiff:
+		return tcell.ColorYellow
+	case cmpLeftOnly:
+		return tcell.ColorGreen
+	case cmpRightOnly:
+		return tcell.ColorAqua
+	}
+	return tcell.ColorWhite
+}
+
+// syncDirection direction to copy entries
+type syncDirection int
+
+const (
+	syncToRight syncDirection = iota
+	syncToLeft
+	syncBoth
+)
+
+// cmpNode entry which exists in the left or the right directory
+type cmpNode struct {
+	name     string
+	rel      string
+	status   cmpStatus
+	left     os.FileInfo
+	right    os.FileInfo
+	children []*cmpNode
+}
+
+// IsDir return true if the entry is a directory on the sides where it exists
+func (n *cmpNode) IsDir() bool {
+	return (n.left == nil || n.left.IsDir()) && (n.right == nil || n.right.IsDir())
+}
+
+// Conflict return true if the entry is a directory on one side and a file on the other
+func (n *cmpNode) Conflict() bool {
+	return n.left != nil && n.right != nil && n.left.IsDir() != n.right.IsDir()
+}
+
+// sameContent compare contents of two files
+func sameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, compareBufferSize)
+	bufB := make([]byte, compareBufferSize)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
+
+// sameFile compare files by size and modified time, or by content
+func sameFile(left, right string, l, r os.FileInfo, content bool) bool {
+	if l.Mode()&os.ModeSymlink != 0 || r.Mode()&os.ModeSymlink != 0 {
+		lt, _ := os.Readlink(left)
+		rt, _ := os.Readlink(right)
+		return l.Mode()&os.ModeSymlink != 0 && r.Mode()&os.ModeSymlink != 0 && lt == rt
+	}
+	if l.Size() != r.Size() {
+		return false
+	}
+	if !content {
+		// file systems have different precision of time
+		return l.ModTime().Truncate(time.Second).Equal(r.ModTime().Truncate(time.Second))
+	}
+
+	same, err := sameContent(left, right)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return same
+}
+
+func readDirInfo(dir string) map[string]os.FileInfo {
+	infos := make(map[string]os.FileInfo)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("%s: %s\n", ErrReadDir, err)
+		}
+		return infos
+	}
+	for _, e := range entries {
+		infos[e.Name()] = e
+	}
+	return infos
+}
+
+// compareDirs compare entries of the left and the right directory recursively
+func compareDirs(ctx context.Context, left, right, rel string, content bool, progress *int64) ([]*cmpNode, error) {
+	lefts := readDirInfo(filepath.Join(left, rel))
+	rights := readDirInfo(filepath.Join(right, rel))
+
+	var names []string
+	for name := range lefts {
+		names = append(names, name)
+	}
+	for name := range rights {
+		if _, ok := lefts[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var nodes []*cmpNode
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		atomic.AddInt64(progress, 1)
+
+		n := &cmpNode{
+			name:  name,
+			rel:   filepath.Join(rel, name),
+			left:  lefts[name],
+			right: rights[name],
+		}
+		switch {
+		case n.right == nil:
+			n.status = cmpLeftOnly
+		case n.left == nil:
+			n.status = cmpRightOnly
+		case n.Conflict():
+			n.status = cmpDiff
+		case n.IsDir():
+			children, err := compareDirs(ctx, left, right, n.rel, content, progress)
+			if err != nil {
+				return nil, err
+			}
+			n.children = children
+			for _, c := range children {
+				if c.status != cmpSame {
+					n.status = cmpDiff
+					break
+				}
+			}
+		case !sameFile(filepath.Join(left, n.rel), filepath.Join(right, n.rel), n.left, n.right, content):
+			n.status = cmpDiff
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// syncOp copy the entry to the right, or to the left
+type syncOp struct {
+	rel     string
+	toRight bool
+}
+
+func (op syncOp) Apply(left, right string) error {
+	if op.toRight {
+		return system.Sync(filepath.Join(left, op.rel), filepath.Join(right, op.rel))
+	}
+	return system.Sync(filepath.Join(right, op.rel), filepath.Join(left, op.rel))
+}
+
+// planSync make copies of missing or newer entries under the node,
+// entries which can't be decided are counted as skipped
+func planSync(n *cmpNode, dir syncDirection) (ops []syncOp, skipped int) {
+	toRight := syncOp{rel: n.rel, toRight: true}
+	toLeft := syncOp{rel: n.rel}
+
+	switch n.status {
+	case cmpLeftOnly:
+		if dir != syncToLeft {
+			ops = append(ops, toRight)
+		}
+	case cmpRightOnly:
+		if dir != syncToRight {
+			ops = append(ops, toLeft)
+		}
+	case cmpDiff:
+		switch {
+		case n.Conflict():
+			skipped++
+		case n.IsDir():
+			for _, c := range n.children {
+				o, s := planSync(c, dir)
+				ops = append(ops, o...)
+				skipped += s
+			}
+		case n.left.ModTime().After(n.right.ModTime()):
+			if dir != syncToLeft {
+				ops = append(ops, toRight)
+			}
+		case n.right.ModTime().After(n.left.ModTime()):
+			if dir != syncToRight {
+				ops = append(ops, toLeft)
+			}
+		default:
+			// same time but different contents
+			skipped++
+		}
+	}
+	return ops, skipped
+}
+
+func syncSummary(ops []syncOp) string {
+	var lines []string
+	for i, op := range ops {
+		if i == maxRenameSummary {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(ops)-i))
+			break
+		}
+		if op.toRight {
+			lines = append(lines, op.rel+" ->")
+		} else {
+			lines = append(lines, "<- "+op.rel)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isNested return true if a directory contains the other
+func isNested(a, b string) bool {
+	for _, rel := range []string{relPath(a, b), relPath(b, a)} {
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+func relPath(base, target string) string {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return ".."
+	}
+	return rel
+}
+
+// Compare differences between two directory trees
+type Compare struct {
+	*tview.TreeView
+	left     string
+	right    string
+	content  bool
+	hideSame bool
+	nodes    []*cmpNode
+	cancel   context.CancelFunc
+}
+
+func NewCompare() *Compare {
+	c := &Compare{
+		TreeView: tview.NewTreeView(),
+	}
+	c.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+	return c
+}
+
+// Run compare the directories in background with the progress, and show the differences when it's done
+func (c *Compare) Run(gui *Gui, left, right string, content bool) error {
+	return c.run(gui, left, right, content, nil)
+}
+
+// run compare like Run, and call done in the event loop after the differences are shown
+func (c *Compare) run(gui *Gui, left, right string, content bool, done func()) error {
+	if s3.IsPath(left) || s3.IsPath(right) {
+		return ErrNotSupported
+	}
+	for _, dir := range []string{left, right} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s: %s", ErrNotDir, dir)
+		}
+	}
+	// a directory would be copied into itself
+	if isNested(left, right) {
+		return ErrNestedDirs
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	pageName := "compare_scan"
+	var progress int64
+	// the scan is started again from the compare view after sync
+	stop := gui.Progress(pageName, gui.CurrentPanel, cancel, func() string {
+		return fmt.Sprintf("comparing %s and %s\n\n%d entries", left, right, atomic.LoadInt64(&progress))
+	})
+
+	go func() {
+		nodes, err := compareDirs(ctx, left, right, "", content, &progress)
+		stop()
+		if err != nil {
+			return
+		}
+
+		gui.App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			gui.Pages.RemovePage(pageName)
+			c.Show(gui, left, right, content, nodes)
+			if done != nil {
+				done()
+			}
+		})
+	}()
+	return nil
+}
+
+// Show show the differences
+func (c *Compare) Show(gui *Gui, left, right string, content bool, nodes []*cmpNode) {
+	c.left = left
+	c.right = right
+	c.content = content
+	c.nodes = nodes
+	c.UpdateView()
+
+	gui.Pages.AddAndSwitchToPage("compare", c, true)
+	gui.FocusPanel(ComparePanel)
+}
+
+func (c *Compare) Close(gui *Gui) {
+	c.nodes = nil
+	gui.Pages.RemovePage("compare").SwitchToPage("main")
+	gui.FocusPanel(FileTablePanel)
+	gui.FileBrowser.UpdateView()
+}
+
+func (c *Compare) addNodes(target *tview.TreeNode, nodes []*cmpNode, counts map[cmpStatus]int) {
+	for _, n := range nodes {
+		// directories which have been compared are counted by the children
+		if len(n.children) == 0 {
+			counts[n.status]++
+		}
+		if c.hideSame && n.status == cmpSame {
+			continue
+		}
+
+		name := n.name
+		if n.IsDir() {
+			name += "/"
+		}
+		node := tview.NewTreeNode(n.status.String() + " " + name).
+			SetReference(n).
+			SetColor(n.status.Color())
+		c.addNodes(node, n.children, counts)
+		node.SetExpanded(n.status != cmpSame)
+		target.AddChild(node)
+	}
+}
+
+func (c *Compare) UpdateView() {
+	root := tview.NewTreeNode(c.left + " <-> " + c.right).
+		SetReference(&cmpNode{status: cmpDiff, children: c.nodes}).
+		SetColor(tcell.ColorYellow)
+
+	counts := make(map[cmpStatus]int)
+	c.addNodes(root, c.nodes, counts)
+	c.SetRoot(root).SetCurrentNode(root)
+
+	mode := "size and time"
+	if c.content {
+		mode = "content"
+	}
+	c.SetTitle(fmt.Sprintf("compare by %s: %d different, %d left only, %d right only, %d same",
+		mode, counts[cmpDiff], counts[cmpLeftOnly], counts[cmpRightOnly], counts[cmpSame]))
+}
+
+// Sync copy missing or newer entries under the selected node after the summary is confirmed
+func (c *Compare) Sync(gui *Gui, dir syncDirection) {
+	node := c.GetCurrentNode()
+	if node == nil {
+		return
+	}
+	n := node.GetReference().(*cmpNode)
+
+	var ops []syncOp
+	var skipped int
+	if n.rel == "" {
+		// the root has only the children
+		for _, child := range n.children {
+			o, s := planSync(child, dir)
+			ops = append(ops, o...)
+			skipped += s
+		}
+	} else {
+		ops, skipped = planSync(n, dir)
+	}
+
+	if len(ops) == 0 {
+		gui.Message(fmt.Sprintf("nothing to copy, %d skipped", skipped), ComparePanel)
+		return
+	}
+
+	message := fmt.Sprintf("do you want to copy %d entries? %d skipped\n\n%s", len(ops), skipped, syncSummary(ops))
+	gui.Confirm(message, "copy", ComparePanel, func() error {
+		c.applySync(gui, ops)
+		return nil
+	})
+}
+
+// applySync copy entries in background with the progress, and compare the directories again
+func (c *Compare) applySync(gui *Gui, ops []syncOp) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	pageName := "compare_sync"
+	var copied int64
+	stop := gui.Progress(pageName, ComparePanel, cancel, func() string {
+		return fmt.Sprintf("copying %d/%d entries", atomic.LoadInt64(&copied), len(ops))
+	})
+
+	go func() {
+		err := syncEntries(ctx, c.left, c.right, ops, &copied)
+		stop()
+
+		gui.App.QueueUpdateDraw(func() {
+			gui.Pages.RemovePage(pageName)
+			// entries which have been copied before the failure or the cancel are not different anymore,
+			// so the error is shown over the new differences
+			var done func()
+			if err != nil && err != context.Canceled {
+				done = func() {
+					gui.Message(err.Error(), ComparePanel)
+				}
+			}
+			if err := c.run(gui, c.left, c.right, c.content, done); err != nil {
+				gui.Message(err.Error(), ComparePanel)
+			}
+		})
+	}()
+}
+
+// syncEntries apply the copies until one fails or ctx is done
+func syncEntries(ctx context.Context, left, right string, ops []syncOp, copied *int64) error {
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := op.Apply(left, right); err != nil {
+			log.Println(err)
+			return err
+		}
+		atomic.AddInt64(copied, 1)
+	}
+	return nil
+}
+
+func (c *Compare) Keybinding(gui *Gui) {
+	c.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyF1:
+			gui.Help.UpdateView(ComparePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("compare")
+			return nil
+		case tcell.KeyEscape:
+			c.Close(gui)
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'q':
+			c.Close(gui)
+		case '?':
+			gui.Help.UpdateView(ComparePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("compare")
+		case 'l':
+			if node := c.GetCurrentNode(); node != nil {
+				node.SetExpanded(true)
+			}
+		case 'h':
+			if node := c.GetCurrentNode(); node != nil {
+				node.SetExpanded(false)
+			}
+		case 's':
+			c.hideSame = !c.hideSame
+			c.UpdateView()
+		case 'r':
+			if err := c.Run(gui, c.left, c.right, c.content); err != nil {
+				gui.Message(err.Error(), ComparePanel)
+			}
+		case '>':
+			c.Sync(gui, syncToRight)
+		case '<':
+			c.Sync(gui, syncToLeft)
+		case 'b':
+			c.Sync(gui, syncBoth)
+		default:
+			return event
+		}
+		return nil
+	})
+}
+
+// CompareForm choose the directory to compare with the current directory
+func (gui *Gui) CompareForm() {
+	current := gui.InputPath.GetText()
+	if s3.IsPath(current) {
+		gui.Message(ErrNotSupported.Error(), FileTablePanel)
+		return
+	}
+
+	// the selected directory is inside the current one, so the previous target is the default
+	target := gui.Compare.right
+
+	pageName := "compare_target"
+	form := tview.NewForm().
+		AddInputField("target", target, 0, nil, nil).
+		AddCheckbox("compare content", false, nil)
+
+	form.AddButton("compare", func() {
+		target := os.ExpandEnv(form.GetFormItemByLabel("target").(*tview.InputField).GetText())
+		content := form.GetFormItemByLabel("compare content").(*tview.Checkbox).IsChecked()
+		if target == "" {
+			gui.Message(ErrNoDirName.Error(), FileTablePanel)
+			return
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(current, target)
+		}
+
+		gui.Pages.RemovePage(pageName)
+		if err := gui.Compare.Run(gui, current, filepath.Clean(target), content); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+	})
+
+	form.SetTitle("compare " + current + " with")
+	gui.showForm(form, pageName, 60, 9)
+}
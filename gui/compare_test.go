@@ -0,0 +1,230 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSameContent(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	large := make([]byte, compareBufferSize+10)
+	changed := make([]byte, len(large))
+	changed[len(changed)-1] = 1
+	writeTestContents(t, dir, map[string]string{
+		"empty":   "",
+		"a":       "a",
+		"b":       "b",
+		"ab":      "ab",
+		"large":   string(large),
+		"large2":  string(large),
+		"changed": string(changed),
+	})
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"empty", "empty", true},
+		{"a", "a", true},
+		{"a", "b", false},
+		{"a", "ab", false},
+		{"ab", "a", false},
+		{"empty", "a", false},
+		{"large", "large2", true},
+		{"large", "changed", false},
+	}
+	for _, tt := range tests {
+		got, err := sameContent(filepath.Join(dir, tt.a), filepath.Join(dir, tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("sameContent(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// compareTestDirs create the left and the right directory, new files are modified later than old files
+func compareTestDirs(t *testing.T) (left, right string) {
+	t.Helper()
+	dir := testDir(t)
+	left, right = filepath.Join(dir, "left"), filepath.Join(dir, "right")
+	writeTestFiles(t, left, "same", "left_only", "newer_left", "older_left", "sub/same", "sub/left_only", "conflict")
+	writeTestFiles(t, right, "same", "right_only", "newer_left", "older_left", "sub/same", "conflict/file")
+
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"same", "sub/same"} {
+		for _, root := range []string{left, right} {
+			if err := os.Chtimes(filepath.Join(root, name), old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// the contents have the same size, so they are compared by time
+	if err := os.Chtimes(filepath.Join(right, "newer_left"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(left, "older_left"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	return left, right
+}
+
+func TestPlanSync(t *testing.T) {
+	left, right := compareTestDirs(t)
+	defer os.RemoveAll(filepath.Dir(left))
+
+	var progress int64
+	nodes, err := compareDirs(context.Background(), left, right, "", false, &progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &cmpNode{status: cmpDiff, children: nodes}
+
+	tests := []struct {
+		name        string
+		dir         syncDirection
+		want        []syncOp
+		wantSkipped int
+	}{
+		{
+			name: "to right",
+			dir:  syncToRight,
+			want: []syncOp{
+				{rel: "left_only", toRight: true},
+				{rel: "newer_left", toRight: true},
+				{rel: filepath.Join("sub", "left_only"), toRight: true},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "to left",
+			dir:  syncToLeft,
+			want: []syncOp{
+				{rel: "older_left"},
+				{rel: "right_only"},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "both",
+			dir:  syncBoth,
+			want: []syncOp{
+				{rel: "left_only", toRight: true},
+				{rel: "newer_left", toRight: true},
+				{rel: "older_left"},
+				{rel: "right_only"},
+				{rel: filepath.Join("sub", "left_only"), toRight: true},
+			},
+			wantSkipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []syncOp
+			var skipped int
+			for _, n := range root.children {
+				o, s := planSync(n, tt.dir)
+				ops = append(ops, o...)
+				skipped += s
+			}
+			if !reflect.DeepEqual(ops, tt.want) {
+				t.Errorf("ops = %+v, want %+v", ops, tt.want)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestSyncEntries(t *testing.T) {
+	left, right := compareTestDirs(t)
+	defer os.RemoveAll(filepath.Dir(left))
+
+	ops := []syncOp{
+		{rel: "left_only", toRight: true},
+		{rel: "newer_left", toRight: true},
+		{rel: "right_only"},
+		{rel: "sub", toRight: true},
+	}
+	var copied int64
+	if err := syncEntries(context.Background(), left, right, ops, &copied); err != nil {
+		t.Fatal(err)
+	}
+	if copied != int64(len(ops)) {
+		t.Errorf("copied = %d, want %d", copied, len(ops))
+	}
+
+	// copied files keep the modified time
+	var progress int64
+	nodes, err := compareDirs(context.Background(), left, right, "", false, &progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		want := cmpSame
+		switch n.name {
+		case "older_left", "conflict":
+			want = cmpDiff
+		}
+		if n.status != want {
+			t.Errorf("%s: status = %s, want %s", n.name, n.status, want)
+		}
+	}
+
+	// nothing is copied after the cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	copied = 0
+	if err := syncEntries(ctx, left, right, []syncOp{{rel: "older_left"}}, &copied); err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if copied != 0 {
+		t.Errorf("copied = %d after the cancel", copied)
+	}
+}
+
+func TestSyncEntriesFailure(t *testing.T) {
+	left, right := compareTestDirs(t)
+	defer os.RemoveAll(filepath.Dir(left))
+
+	// the entry is removed after the plan
+	ops := []syncOp{
+		{rel: "left_only", toRight: true},
+		{rel: "removed", toRight: true},
+		{rel: "newer_left", toRight: true},
+	}
+	var copied int64
+	if err := syncEntries(context.Background(), left, right, ops, &copied); err == nil {
+		t.Fatal("err = nil, want the error of the removed entry")
+	}
+	if copied != 1 {
+		t.Errorf("copied = %d, want 1", copied)
+	}
+
+	// the entry copied before the failure is the same in the new comparison
+	var progress int64
+	nodes, err := compareDirs(context.Background(), left, right, "", false, &progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		switch n.name {
+		case "left_only":
+			if n.status != cmpSame {
+				t.Errorf("%s: status = %s, want %s", n.name, n.status, cmpSame)
+			}
+		case "newer_left":
+			if n.status != cmpDiff {
+				t.Errorf("%s: status = %s, want %s", n.name, n.status, cmpDiff)
+			}
+		}
+	}
+}
@@ -1,31 +1,83 @@
 package gui
 
+import (
+	"os"
+	"time"
+)
+
 type LogConfig struct {
 	Enable bool   `yaml:"enable"`
 	File   string `yaml:"file"`
 }
 
+// PreviewHandler external previewer command.
+// Match is a list of extensions (.pdf), globs (*.tar.gz) or MIME types (video/*).
+// "{}" in Command is replaced with the file path, or the path is appended.
+type PreviewHandler struct {
+	Match   []string `yaml:"match"`
+	Command string   `yaml:"command"`
+}
+
 type PreviewConfig struct {
-	Enable      bool   `yaml:"enable"`
-	Colorscheme string `yaml:"colorscheme"`
+	Enable         bool             `yaml:"enable"`
+	Colorscheme    string           `yaml:"colorscheme"`
+	MaxSize        int64            `yaml:"max_size"`
+	HeadOnly       bool             `yaml:"head_only"`
+	HeadLines      int              `yaml:"head_lines"`
+	HeadBytes      int64            `yaml:"head_bytes"`
+	CacheSize      int              `yaml:"cache_size"`
+	Handlers       []PreviewHandler `yaml:"handlers"`
+	HandlerTimeout time.Duration    `yaml:"handler_timeout"`
+	HandlerMaxSize int64            `yaml:"handler_max_size"`
+	DirDepth       int              `yaml:"dir_depth"`
+	DirMaxEntries  int              `yaml:"dir_max_entries"`
 }
 
+// BookmarkConfig Sort is "usage" or "name"
 type BookmarkConfig struct {
 	Enable bool   `yaml:"enable"`
 	File   string `yaml:"file"`
 	Log    bool   `yaml:"log"`
+	Sort   string `yaml:"sort"`
+}
+
+type GitConfig struct {
+	Enable bool `yaml:"enable"`
+}
+
+// IgnoreConfig hide entries ignored by git, or matched by Patterns (node_modules, *.pyc)
+type IgnoreConfig struct {
+	Enable    bool     `yaml:"enable"`
+	Gitignore bool     `yaml:"gitignore"`
+	Patterns  []string `yaml:"patterns"`
+}
+
+type S3Config struct {
+	Endpoint     string `yaml:"endpoint"`
+	Region       string `yaml:"region"`
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	SessionToken string `yaml:"session_token"`
 }
 
 type Config struct {
-	ConfigDir  string
-	ConfigFile string
-	Log        LogConfig      `yaml:"log"`
-	Preview    PreviewConfig  `yaml:"preview"`
-	Bookmark   BookmarkConfig `yaml:"bookmark"`
-	IgnoreCase bool           `yaml:"ignore_case"`
-	OpenCmd    string         `yaml:"open_cmd"`
-	EnableTree bool           `yaml:"enable_tree"`
-	ShowHidden bool           `yaml:"show_hidden"`
+	ConfigDir      string
+	ConfigFile     string
+	Log            LogConfig      `yaml:"log"`
+	Preview        PreviewConfig  `yaml:"preview"`
+	Bookmark       BookmarkConfig `yaml:"bookmark"`
+	S3             S3Config       `yaml:"s3"`
+	Git            GitConfig      `yaml:"git"`
+	Ignore         IgnoreConfig   `yaml:"ignore"`
+	IgnoreCase     bool           `yaml:"ignore_case"`
+	OpenCmd        string         `yaml:"open_cmd"`
+	EnableTree     bool           `yaml:"enable_tree"`
+	ShowHidden     bool           `yaml:"show_hidden"`
+	FollowSymlinks bool           `yaml:"follow_symlinks"`
+	DirSize        bool           `yaml:"dir_size"`
+	RememberState  bool           `yaml:"remember_state"`
+	FileMode       os.FileMode    `yaml:"file_mode"`
+	DirMode        os.FileMode    `yaml:"dir_mode"`
 }
 
 func DefaultConfig() Config {
@@ -34,15 +86,33 @@ func DefaultConfig() Config {
 			Enable: false,
 		},
 		Preview: PreviewConfig{
-			Enable:      false,
-			Colorscheme: "monokai",
+			Enable:         false,
+			Colorscheme:    "monokai",
+			MaxSize:        defaultMaxPreviewSize,
+			HeadOnly:       false,
+			HeadLines:      defaultHeadLines,
+			HeadBytes:      defaultHeadBytes,
+			CacheSize:      defaultCacheSize,
+			HandlerTimeout: defaultHandlerTimeout,
+			HandlerMaxSize: defaultHandlerMaxSize,
+			DirDepth:       defaultDirDepth,
+			DirMaxEntries:  defaultDirMaxEntries,
 		},
 		Bookmark: BookmarkConfig{
 			Enable: false,
 			Log:    false,
+			Sort:   bookmarkSortUsage,
+		},
+		Git: GitConfig{
+			Enable: true,
+		},
+		Ignore: IgnoreConfig{
+			Enable:    false,
+			Gitignore: true,
 		},
-		IgnoreCase: false,
-		EnableTree: false,
-		ShowHidden: false,
+		IgnoreCase:     false,
+		EnableTree:     false,
+		ShowHidden:     false,
+		FollowSymlinks: true,
 	}
 }
@@ -0,0 +1,348 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	// lines around changes
+	diffContext = 3
+	// give up finding the shortest diff when files differ too much
+	maxDiffEdits = 2000
+)
+
+const (
+	diffEqual = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp line of diff, a and b are line indexes of each file
+type diffOp struct {
+	kind int
+	a, b int
+}
+
+type diffHunk struct {
+	ops                        []diffOp
+	aStart, aLen, bStart, bLen int
+}
+
+// fileDiff line diff of two files
+type fileDiff struct {
+	aName, bName string
+	a, b         []string
+	hunks        []diffHunk
+}
+
+func splitLines(text string) []string {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func newFileDiff(aName, bName, a, b string) *fileDiff {
+	d := &fileDiff{
+		aName: aName,
+		bName: bName,
+		a:     splitLines(a),
+		b:     splitLines(b),
+	}
+	d.hunks = makeHunks(diffLines(d.a, d.b))
+	return d
+}
+
+// diffLines diff lines with Myers' algorithm
+func diffLines(a, b []string) []diffOp {
+	// common prefix and suffix don't need to be searched
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{diffEqual, i, i})
+	}
+	for _, op := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.a += prefix
+		op.b += prefix
+		ops = append(ops, op)
+	}
+	for i := suffix; i > 0; i-- {
+		ops = append(ops, diffOp{diffEqual, len(a) - i, len(b) - i})
+	}
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > maxDiffEdits {
+		max = maxDiffEdits
+	}
+
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] is v before the step d, which has k in [-d-1, d+1]
+	var trace [][]int
+	get := func(d, k int) int {
+		return trace[d][k+d+1]
+	}
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(n, m, d, get)
+			}
+		}
+	}
+
+	// too many edits, delete all and insert all
+	var ops []diffOp
+	for i := range a {
+		ops = append(ops, diffOp{diffDelete, i, 0})
+	}
+	for i := range b {
+		ops = append(ops, diffOp{diffInsert, n, i})
+	}
+	return ops
+}
+
+func backtrack(x, y, depth int, get func(d, k int) int) []diffOp {
+	var ops []diffOp
+	for d := depth; d >= 0; d-- {
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(d, k-1) < get(d, k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(d, prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{diffEqual, x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{diffInsert, x, y})
+			} else {
+				x--
+				ops = append(ops, diffOp{diffDelete, x, y})
+			}
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// makeHunks group changes with context lines
+func makeHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == diffEqual {
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		// extend while the next change is close enough
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != diffEqual {
+				end = j
+			} else if j-end > diffContext*2 {
+				break
+			}
+		}
+		end += diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		h := diffHunk{ops: ops[start:end]}
+		h.aStart, h.bStart = ops[start].a, ops[start].b
+		for _, op := range h.ops {
+			if op.kind != diffInsert {
+				h.aLen++
+			}
+			if op.kind != diffDelete {
+				h.bLen++
+			}
+		}
+		hunks = append(hunks, h)
+		i = end - 1
+	}
+	return hunks
+}
+
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.aStart+1, h.aLen, h.bStart+1, h.bLen)
+}
+
+// Unified render diff as unified format
+func (d *fileDiff) Unified() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "[::b]--- %s[::-]\n", tview.Escape(d.aName))
+	fmt.Fprintf(&buf, "[::b]+++ %s[::-]\n", tview.Escape(d.bName))
+
+	for i, h := range d.hunks {
+		fmt.Fprintf(&buf, `["h%d"][aqua]%s[-][""]`+"\n", i, h.header())
+		for _, op := range h.ops {
+			switch op.kind {
+			case diffEqual:
+				buf.WriteString(" " + tview.Escape(d.a[op.a]) + "\n")
+			case diffDelete:
+				buf.WriteString("[red]-" + tview.Escape(d.a[op.a]) + "[-]\n")
+			case diffInsert:
+				buf.WriteString("[green]+" + tview.Escape(d.b[op.b]) + "[-]\n")
+			}
+		}
+	}
+	return buf.String()
+}
+
+// DiffFiles diff two marked files, or the marked file and the selected file
+func (gui *Gui) DiffFiles() error {
+	files := marks.Files()
+	var a, b *File
+	switch len(files) {
+	case 1:
+		a, b = files[0], gui.FileBrowser.GetSelectEntry()
+	case 2:
+		a, b = files[0], files[1]
+	default:
+		return ErrNoDiffFiles
+	}
+	if b == nil || a.IsDir || b.IsDir || a.PathName == b.PathName {
+		return ErrNoDiffFiles
+	}
+
+	var texts []string
+	for _, f := range []*File{a, b} {
+		content, err := readFile(f)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		if isBinary(content) {
+			return ErrDiffBinary
+		}
+		texts = append(texts, string(content))
+	}
+
+	gui.Pager.ShowDiff(gui, newFileDiff(a.PathName, b.PathName, texts[0], texts[1]))
+	return nil
+}
+
+// fitWidth cut or pad text to the width
+func fitWidth(text string, width int) string {
+	text = strings.Replace(text, "\t", "    ", -1)
+
+	var buf strings.Builder
+	var w int
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		rw := tview.TaggedStringWidth(string(r))
+		if w+rw > width {
+			break
+		}
+		buf.WriteRune(r)
+		w += rw
+		text = text[size:]
+	}
+	return buf.String() + strings.Repeat(" ", width-w)
+}
+
+// SideBySide render diff as two columns
+func (d *fileDiff) SideBySide(width int) string {
+	// line number, text and separator
+	column := (width - 3) / 2
+	textWidth := column - 5
+	if textWidth < 1 {
+		textWidth = 1
+	}
+
+	side := func(lines []string, i int, color string) string {
+		if i < 0 {
+			return strings.Repeat(" ", textWidth+5)
+		}
+		return fmt.Sprintf("[gray]%4d[-] %s%s[-]", i+1, color, tview.Escape(fitWidth(lines[i], textWidth)))
+	}
+	row := func(left, right string) string {
+		return left + " [gray]│[-] " + right + "\n"
+	}
+
+	var buf strings.Builder
+	buf.WriteString(row("[::b]"+tview.Escape(fitWidth(d.aName, textWidth+5))+"[::-]",
+		"[::b]"+tview.Escape(d.bName)+"[::-]"))
+
+	for i, h := range d.hunks {
+		fmt.Fprintf(&buf, `["h%d"][aqua]%s[-][""]`+"\n", i, h.header())
+
+		// pair deleted and inserted lines as changed lines
+		var deleted, inserted []int
+		flush := func() {
+			for j := 0; j < len(deleted) || j < len(inserted); j++ {
+				a, b := -1, -1
+				if j < len(deleted) {
+					a = deleted[j]
+				}
+				if j < len(inserted) {
+					b = inserted[j]
+				}
+				buf.WriteString(row(side(d.a, a, "[red]"), side(d.b, b, "[green]")))
+			}
+			deleted, inserted = nil, nil
+		}
+
+		for _, op := range h.ops {
+			switch op.kind {
+			case diffEqual:
+				flush()
+				buf.WriteString(row(side(d.a, op.a, ""), side(d.b, op.b, "")))
+			case diffDelete:
+				deleted = append(deleted, op.a)
+			case diffInsert:
+				inserted = append(inserted, op.b)
+			}
+		}
+		flush()
+	}
+	return buf.String()
+}
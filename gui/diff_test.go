@@ -0,0 +1,141 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// applyOps rebuild both files from the ops
+func applyOps(a, b []string, ops []diffOp) (gotA, gotB []string) {
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			if a[op.a] != b[op.b] {
+				return nil, nil
+			}
+			gotA = append(gotA, a[op.a])
+			gotB = append(gotB, b[op.b])
+		case diffDelete:
+			gotA = append(gotA, a[op.a])
+		case diffInsert:
+			gotB = append(gotB, b[op.b])
+		}
+	}
+	return gotA, gotB
+}
+
+func countEdits(ops []diffOp) int {
+	var edits int
+	for _, op := range ops {
+		if op.kind != diffEqual {
+			edits++
+		}
+	}
+	return edits
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		edits int
+	}{
+		{"empty", "", "", 0},
+		{"same", "a\nb\nc", "a\nb\nc", 0},
+		{"insert all", "", "a\nb", 2},
+		{"delete all", "a\nb", "", 2},
+		{"insert", "a\nc", "a\nb\nc", 1},
+		{"delete", "a\nb\nc", "a\nc", 1},
+		{"replace", "a\nb\nc", "a\nx\nc", 2},
+		{"move", "a\nb\nc\nd", "b\nc\nd\na", 2},
+		{"classic", "a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := splitLines(tt.a), splitLines(tt.b)
+			ops := diffLines(a, b)
+			gotA, gotB := applyOps(a, b, ops)
+			if strings.Join(gotA, "\n") != tt.a || strings.Join(gotB, "\n") != tt.b {
+				t.Fatalf("ops %v don't rebuild the files", ops)
+			}
+			// Myers finds the shortest edit script
+			if got := countEdits(ops); got != tt.edits {
+				t.Errorf("edits = %d, want %d", got, tt.edits)
+			}
+		})
+	}
+}
+
+func TestDiffLinesTooManyEdits(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	ops := diffLines(a, b)
+	gotA, gotB := applyOps(a, b, ops)
+	if len(gotA) != len(a) || len(gotB) != len(b) {
+		t.Fatalf("ops don't rebuild the files")
+	}
+	if got := countEdits(ops); got != len(a)+len(b) {
+		t.Errorf("edits = %d, want %d", got, len(a)+len(b))
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"a\n", 1},
+		{"a\r\nb\r\n", 2},
+		{"a\n\nb", 3},
+	}
+	for _, tt := range tests {
+		if got := len(splitLines(tt.text)); got != tt.want {
+			t.Errorf("len(splitLines(%q)) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+	}
+	b := append([]string(nil), a...)
+	// far apart changes make two hunks, close ones are merged
+	b[1] = "changed 2"
+	b[3] = "changed 4"
+	b[17] = "changed 18"
+
+	d := newFileDiff("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"))
+	var headers []string
+	for _, h := range d.hunks {
+		headers = append(headers, h.header())
+	}
+	want := []string{"@@ -1,7 +1,7 @@", "@@ -15,6 +15,6 @@"}
+	if strings.Join(headers, " ") != strings.Join(want, " ") {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+
+	unified := d.Unified()
+	for _, line := range []string{"[red]-line 2[-]", "[green]+changed 2[-]", " line 3", "[green]+changed 18[-]"} {
+		if !strings.Contains(unified, line+"\n") {
+			t.Errorf("Unified() doesn't have %q", line)
+		}
+	}
+	if strings.Contains(unified, "line 10\n") {
+		t.Errorf("Unified() has a line out of the context")
+	}
+}
+
+func TestDiffHunksSame(t *testing.T) {
+	d := newFileDiff("a", "b", "a\nb\n", "a\nb")
+	if len(d.hunks) != 0 {
+		t.Errorf("hunks = %v, want none", d.hunks)
+	}
+}
@@ -0,0 +1,213 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/skanehira/ff/s3"
+)
+
+// sizes are recomputed after this, because changes deeper in the tree
+// don't change the modified time of the directory
+const dirSizeMaxAge = 30 * time.Second
+
+// dirSize recursive size of the directory when it was modified at modified
+type dirSize struct {
+	modified string
+	computed time.Time
+	size     int64
+}
+
+// dirSizeCache recursive sizes of directories which is safe for concurrent use
+type dirSizeCache struct {
+	mu      sync.Mutex
+	sizes   map[string]dirSize
+	pending map[string]context.Context
+}
+
+var dirSizes = &dirSizeCache{
+	sizes:   make(map[string]dirSize),
+	pending: make(map[string]context.Context),
+}
+
+// Get get the size of the directory, the size is stale if the directory has been modified
+func (c *dirSizeCache) Get(f *File) (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sizes[f.PathName]
+	if !ok || s.modified != f.Change {
+		return 0, false
+	}
+	return s.size, true
+}
+
+func (c *dirSizeCache) Set(f *File, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sizes[f.PathName] = dirSize{modified: f.Change, computed: time.Now(), size: size}
+	delete(c.pending, f.PathName)
+}
+
+// Stale return true if the size should be recomputed
+func (c *dirSizeCache) Stale(f *File) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sizes[f.PathName]
+	return !ok || s.modified != f.Change || time.Since(s.computed) > dirSizeMaxAge
+}
+
+func (c *dirSizeCache) Delete(f *File) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sizes, f.PathName)
+}
+
+// Pending return true if the size of the directory is being computed
+func (c *dirSizeCache) Pending(f *File) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, ok := c.pending[f.PathName]
+	return ok && ctx.Err() == nil
+}
+
+// SetPending mark the directory as being computed until ctx is canceled
+func (c *dirSizeCache) SetPending(ctx context.Context, f *File) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[f.PathName] = ctx
+}
+
+// calcDirSize sum sizes of all files under the directory, symlinks are not followed
+func calcDirSize(ctx context.Context, dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// skip unreadable entries
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// entrySize computed size of the directory if it exists, otherwise the size of the entry
+func entrySize(f *File) int64 {
+	if f.IsDir {
+		if size, ok := dirSizes.Get(f); ok {
+			return size
+		}
+	}
+	return f.Size
+}
+
+// sortBySize sort files from the largest
+func sortBySize(files []*File) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return entrySize(files[i]) > entrySize(files[j])
+	})
+}
+
+// RefreshDirSizes compute sizes of directories in the current directory in background
+// if dir_size is enabled, sizes which have been computed are reused until the directory is modified
+// or dirSizeMaxAge passes
+func (gui *Gui) RefreshDirSizes() {
+	if gui.Config.DirSize {
+		gui.computeDirSizes(false)
+	}
+}
+
+// ComputeDirSizes recompute sizes of directories in the current directory in background
+func (gui *Gui) ComputeDirSizes() error {
+	if s3.IsPath(gui.InputPath.GetText()) {
+		return ErrNotSupported
+	}
+	if _, ok := gui.FileBrowser.(*FileTable); !ok {
+		return ErrNotInTree
+	}
+	gui.computeDirSizes(true)
+	return nil
+}
+
+func (gui *Gui) computeDirSizes(force bool) {
+	table, ok := gui.FileBrowser.(*FileTable)
+	dir := gui.InputPath.GetText()
+	if !ok || s3.IsPath(dir) {
+		return
+	}
+
+	var targets []*File
+	running := true
+	for _, f := range table.Entries() {
+		// symlinks are not followed to avoid counting twice
+		if !f.IsDir || f.IsLink {
+			continue
+		}
+		if force {
+			dirSizes.Delete(f)
+		}
+		if dirSizes.Stale(f) {
+			running = running && dirSizes.Pending(f)
+			targets = append(targets, f)
+		}
+	}
+
+	// don't restart the computation on every refresh
+	if len(targets) == 0 || (!force && running && dir == gui.sizeDir) {
+		return
+	}
+
+	if gui.sizeCancel != nil {
+		gui.sizeCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	gui.sizeCancel = cancel
+	gui.sizeDir = dir
+
+	for _, f := range targets {
+		dirSizes.SetPending(ctx, f)
+	}
+	table.RefreshView()
+
+	go func() {
+		for _, f := range targets {
+			size, err := calcDirSize(ctx, f.PathName)
+			if err != nil {
+				return
+			}
+			dirSizes.Set(f, size)
+
+			gui.App.QueueUpdateDraw(func() {
+				// the directory has been changed
+				if ctx.Err() == nil && dir == gui.InputPath.GetText() {
+					table.RefreshView()
+				}
+			})
+		}
+	}()
+}
+
+// displaySize humanized size of the entry, computed size is used for directories
+func displaySize(f *File) string {
+	if f.IsDir {
+		if size, ok := dirSizes.Get(f); ok {
+			return humanize.Bytes(uint64(size))
+		}
+		if dirSizes.Pending(f) {
+			return "..."
+		}
+	}
+	return humanize.Bytes(uint64(f.Size))
+}
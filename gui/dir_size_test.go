@@ -0,0 +1,64 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCalcDirSize(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "a", "sub/bb", "sub/deep/ccc")
+
+	size, err := calcDirSize(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// contents of the files are their names
+	if want := int64(len("a") + len("sub/bb") + len("sub/deep/ccc")); size != want {
+		t.Errorf("size = %d, want %d", size, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := calcDirSize(ctx, dir); err == nil {
+		t.Error("canceled computation should fail")
+	}
+}
+
+func TestDirSizeCacheStale(t *testing.T) {
+	cache := &dirSizeCache{
+		sizes:   make(map[string]dirSize),
+		pending: make(map[string]context.Context),
+	}
+	f := &File{PathName: "/dir", Change: "2021-01-01 00:00:00", IsDir: true}
+
+	if !cache.Stale(f) {
+		t.Error("size which is not computed should be stale")
+	}
+
+	cache.Set(f, 10)
+	if cache.Stale(f) {
+		t.Error("computed size should not be stale")
+	}
+	if size, ok := cache.Get(f); !ok || size != 10 {
+		t.Errorf("Get() = %d, %v, want 10, true", size, ok)
+	}
+
+	modified := *f
+	modified.Change = "2021-01-01 00:00:01"
+	if !cache.Stale(&modified) {
+		t.Error("size of the modified directory should be stale")
+	}
+
+	// changes deeper in the tree don't change the modified time
+	cache.sizes[f.PathName] = dirSize{modified: f.Change, computed: time.Now().Add(-dirSizeMaxAge - time.Second), size: 10}
+	if !cache.Stale(f) {
+		t.Error("old size should be stale")
+	}
+	if _, ok := cache.Get(f); !ok {
+		t.Error("old size should be shown until it is recomputed")
+	}
+}
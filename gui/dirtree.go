@@ -0,0 +1,202 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+)
+
+const (
+	defaultDirDepth      = 2
+	defaultDirMaxEntries = 500
+
+	// used when the panel size is unknown
+	defaultDirMaxLines = 100
+)
+
+// dirTree render directory as tree.
+// walking is stopped when the lines fill the panel or the entries reach the limit.
+type dirTree struct {
+	ctx        context.Context
+	showHidden bool
+	// nil if ignored entries are shown
+	ignore     *ignoreWalker
+	maxDepth   int
+	maxEntries int
+	maxLines   int
+	entries    int
+	lines      []string
+}
+
+func (t *dirTree) full() bool {
+	return len(t.lines) >= t.maxLines || t.entries >= t.maxEntries || t.ctx.Err() != nil
+}
+
+func (t *dirTree) ignored(dir string, names []string) map[string]bool {
+	if t.ignore == nil {
+		return nil
+	}
+	return t.ignore.Ignored(dir, names)
+}
+
+func (t *dirTree) readDir(dir string) []os.FileInfo {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	ignored := t.ignored(dir, names)
+
+	var visible []os.FileInfo
+	for _, f := range files {
+		if (t.showHidden || f.Name()[0] != '.') && !ignored[f.Name()] {
+			visible = append(visible, f)
+		}
+	}
+	return visible
+}
+
+// countDir count items in the directory without stat
+func (t *dirTree) countDir(dir string) int {
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return 0
+	}
+	ignored := t.ignored(dir, names)
+
+	var count int
+	for _, name := range names {
+		if (t.showHidden || name[0] != '.') && !ignored[name] {
+			count++
+		}
+	}
+	return count
+}
+
+// walk render the files in the dir, which have been read by the caller to show the count
+func (t *dirTree) walk(dir string, files []os.FileInfo, prefix string, depth int) {
+	for i, f := range files {
+		if t.full() {
+			if t.entries >= t.maxEntries && len(t.lines) < t.maxLines {
+				t.lines = append(t.lines, fmt.Sprintf("%s[gray]… %d more[-]", prefix, len(files)-i))
+			}
+			return
+		}
+		t.entries++
+
+		branch, indent := "├── ", "│   "
+		if i == len(files)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if !f.IsDir() {
+			t.lines = append(t.lines, prefix+branch+fileLine(f.Name(), f.Size()))
+			continue
+		}
+
+		child := filepath.Join(dir, f.Name())
+		if depth >= t.maxDepth {
+			t.lines = append(t.lines, prefix+branch+dirLine(f.Name(), t.countDir(child)))
+			continue
+		}
+
+		// read children first to show the count
+		children := t.readDir(child)
+		t.lines = append(t.lines, prefix+branch+dirLine(f.Name(), len(children)))
+		if len(children) > 0 {
+			t.walk(child, children, prefix+indent, depth+1)
+		}
+	}
+}
+
+func fileLine(name string, size int64) string {
+	return fmt.Sprintf("%s  [gray]%s[-]", tview.Escape(name), humanize.Bytes(uint64(size)))
+}
+
+func dirLine(name string, count int) string {
+	items := "items"
+	if count == 1 {
+		items = "item"
+	}
+	return fmt.Sprintf("[darkcyan]%s/[-]  [gray]%d %s[-]", tview.Escape(name), count, items)
+}
+
+// dirEntry render directory entries as tree
+func (p *Preview) dirEntry(ctx context.Context, dir string, opts previewOptions) string {
+	t := &dirTree{
+		ctx:        ctx,
+		showHidden: opts.showHidden,
+		maxDepth:   p.dirDepth,
+		maxEntries: p.dirMaxEntries,
+		maxLines:   opts.height,
+	}
+	if t.maxLines <= 0 {
+		t.maxLines = defaultDirMaxLines
+	}
+
+	// don't walk s3 recursively, it takes a request per directory
+	if s3.IsPath(dir) {
+		objects, err := s3Client.List(dir)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+
+		var names []string
+		for _, o := range objects {
+			names = append(names, path.Base(o.Key))
+		}
+		var ignored map[string]bool
+		if opts.hideIgnored {
+			ignored = ignores.Ignored(ctx, dir, names)
+		}
+
+		for i, o := range objects {
+			name := path.Base(o.Key)
+			if (!t.showHidden && name[0] == '.') || ignored[name] {
+				continue
+			}
+			if t.full() {
+				if t.entries >= t.maxEntries && len(t.lines) < t.maxLines {
+					t.lines = append(t.lines, fmt.Sprintf("[gray]… %d more[-]", len(objects)-i))
+				}
+				break
+			}
+			t.entries++
+
+			if o.IsPrefix {
+				t.lines = append(t.lines, "[darkcyan]"+tview.Escape(name)+"/[-]")
+			} else {
+				t.lines = append(t.lines, fileLine(name, o.Size))
+			}
+		}
+		return strings.Join(t.lines, "\n")
+	}
+
+	if opts.hideIgnored {
+		t.ignore = ignores.Walker(ctx, dir)
+		defer t.ignore.Close()
+	}
+	t.walk(dir, t.readDir(dir), "", 1)
+	return strings.Join(t.lines, "\n")
+}
@@ -0,0 +1,71 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDirEntry(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "a", "sub/b", "sub/deep/c", "sub/deep/d", ".hidden")
+
+	p := &Preview{dirDepth: 2, dirMaxEntries: defaultDirMaxEntries}
+	tests := []struct {
+		name string
+		opts previewOptions
+		p    *Preview
+		want []string
+	}{
+		{
+			name: "depth",
+			opts: previewOptions{height: 50},
+			p:    p,
+			want: []string{
+				"├── a  [gray]1 B[-]",
+				"└── [darkcyan]sub/[-]  [gray]2 items[-]",
+				"    ├── b  [gray]5 B[-]",
+				"    └── [darkcyan]deep/[-]  [gray]2 items[-]",
+			},
+		},
+		{
+			name: "hidden",
+			opts: previewOptions{height: 50, showHidden: true},
+			p:    &Preview{dirDepth: 1, dirMaxEntries: defaultDirMaxEntries},
+			want: []string{
+				"├── .hidden  [gray]7 B[-]",
+				"├── a  [gray]1 B[-]",
+				"└── [darkcyan]sub/[-]  [gray]2 items[-]",
+			},
+		},
+		{
+			name: "lines",
+			opts: previewOptions{height: 2},
+			p:    p,
+			want: []string{
+				"├── a  [gray]1 B[-]",
+				"└── [darkcyan]sub/[-]  [gray]2 items[-]",
+			},
+		},
+		{
+			name: "entries",
+			opts: previewOptions{height: 50},
+			p:    &Preview{dirDepth: 2, dirMaxEntries: 1},
+			want: []string{
+				"├── a  [gray]1 B[-]",
+				"[gray]… 1 more[-]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.p.dirEntry(context.Background(), dir, tt.opts)
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("dirEntry() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
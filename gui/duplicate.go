@@ -0,0 +1,495 @@
+package gui
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+// dupFile file which has the same content as other files in the group
+type dupFile struct {
+	path    string
+	modTime time.Time
+	// files which have the same inode are hard links
+	inode string
+	group *dupGroup
+}
+
+// dupGroup files which have the same size and content
+type dupGroup struct {
+	size  int64
+	files []*dupFile
+}
+
+// Wasted space which can be freed by keeping one copy
+func (g *dupGroup) Wasted() int64 {
+	inodes := make(map[string]bool)
+	for _, f := range g.files {
+		inodes[f.inode] = true
+	}
+	return g.size * int64(len(inodes)-1)
+}
+
+// dupProgress files which have been scanned and hashed
+type dupProgress struct {
+	files  int64
+	hashed int64
+}
+
+// fileInode identify the file by the device and the inode, hard links have the same one
+func fileInode(path string, info os.FileInfo) string {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%d:%d", stat.Dev, stat.Ino)
+	}
+	return path
+}
+
+// unchanged check the file has the size and the modified time of the scan
+func (f *dupFile) unchanged() error {
+	info, err := os.Lstat(f.path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() != f.group.size || !info.ModTime().Equal(f.modTime) {
+		return fmt.Errorf("%s: %s", ErrDupChanged, f.path)
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// findDuplicates group regular files under the directory by size, and then by content hash,
+// empty files and symlinks are skipped
+func findDuplicates(ctx context.Context, dir string, progress *dupProgress) ([]*dupGroup, error) {
+	sizes := make(map[int64][]*dupFile)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// skip unreadable entries
+		if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+			return nil
+		}
+
+		f := &dupFile{path: path, modTime: info.ModTime(), inode: fileInode(path, info)}
+		sizes[info.Size()] = append(sizes[info.Size()], f)
+		atomic.AddInt64(&progress.files, 1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*dupGroup
+	for size, files := range sizes {
+		if len(files) < 2 {
+			continue
+		}
+
+		hashes := make(map[string][]*dupFile)
+		var order []string
+		for _, f := range files {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			hash, err := hashFile(f.path)
+			atomic.AddInt64(&progress.hashed, 1)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if _, ok := hashes[hash]; !ok {
+				order = append(order, hash)
+			}
+			hashes[hash] = append(hashes[hash], f)
+		}
+
+		for _, hash := range order {
+			g := &dupGroup{size: size, files: hashes[hash]}
+			if g.Wasted() == 0 {
+				continue
+			}
+			for _, f := range g.files {
+				f.group = g
+			}
+			groups = append(groups, g)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].files[0].path < groups[j].files[0].path
+	})
+	return groups, nil
+}
+
+// Duplicates duplicate files in the directory tree
+type Duplicates struct {
+	*tview.Table
+	root   string
+	groups []*dupGroup
+	// file of each row, nil for the header of the group
+	rows   []*dupFile
+	marked map[*dupFile]bool
+	cancel context.CancelFunc
+}
+
+func NewDuplicates() *Duplicates {
+	d := &Duplicates{
+		Table:  tview.NewTable().Select(0, 0).SetFixed(1, 0).SetSelectable(true, false),
+		marked: make(map[*dupFile]bool),
+	}
+	d.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+	return d
+}
+
+// Scan find duplicates in the directory in background with the progress, and show them when it's done
+func (d *Duplicates) Scan(gui *Gui, dir string) error {
+	if s3.IsPath(dir) {
+		return ErrNotSupported
+	}
+
+	if d.cancel != nil {
+		d.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	pageName := "duplicates_scan"
+	progress := &dupProgress{}
+	stop := gui.Progress(pageName, FileTablePanel, cancel, func() string {
+		return fmt.Sprintf("finding duplicates in %s\n\n%d files, %d hashed",
+			dir, atomic.LoadInt64(&progress.files), atomic.LoadInt64(&progress.hashed))
+	})
+
+	go func() {
+		groups, err := findDuplicates(ctx, dir, progress)
+		stop()
+		if err != nil {
+			return
+		}
+
+		gui.App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			gui.Pages.RemovePage(pageName)
+			if len(groups) == 0 {
+				gui.Message("no duplicate files", FileTablePanel)
+				return
+			}
+			d.Show(gui, dir, groups)
+		})
+	}()
+	return nil
+}
+
+// Show show the duplicate groups
+func (d *Duplicates) Show(gui *Gui, root string, groups []*dupGroup) {
+	d.root = root
+	d.groups = groups
+	d.marked = make(map[*dupFile]bool)
+	d.UpdateView()
+	d.Select(2, 0)
+
+	gui.Pages.AddAndSwitchToPage("duplicates", d, true)
+	gui.FocusPanel(DuplicatePanel)
+}
+
+func (d *Duplicates) Close(gui *Gui) {
+	d.groups = nil
+	d.rows = nil
+	d.marked = make(map[*dupFile]bool)
+	gui.Pages.RemovePage("duplicates").SwitchToPage("main")
+	gui.FocusPanel(FileTablePanel)
+	gui.FileBrowser.UpdateView()
+}
+
+func (d *Duplicates) UpdateView() {
+	table := d.Clear()
+	d.rows = []*dupFile{nil}
+
+	var wasted int64
+	for _, g := range d.groups {
+		wasted += g.Wasted()
+	}
+	d.SetTitle(fmt.Sprintf("duplicates: %s %d groups, wasted %s, %d marked (%s)", d.root, len(d.groups),
+		humanize.Bytes(uint64(wasted)), len(d.marked), humanize.Bytes(uint64(d.freed()))))
+
+	for i, h := range []string{"Path", "Modified"} {
+		table.SetCell(0, i, &tview.TableCell{
+			Text:            h,
+			NotSelectable:   true,
+			Align:           tview.AlignLeft,
+			Color:           tcell.ColorYellow,
+			BackgroundColor: tcell.ColorDefault,
+		})
+	}
+
+	for _, g := range d.groups {
+		row := len(d.rows)
+		header := fmt.Sprintf("%d copies of %s, wasted %s", len(g.files), humanize.Bytes(uint64(g.size)),
+			humanize.Bytes(uint64(g.Wasted())))
+		table.SetCell(row, 0, tview.NewTableCell(header).SetTextColor(tcell.ColorDarkCyan).SetSelectable(false))
+		d.rows = append(d.rows, nil)
+
+		for _, f := range g.files {
+			row := len(d.rows)
+			color := tcell.ColorWhite
+			if d.marked[f] {
+				color = markColor
+			}
+			path, err := filepath.Rel(d.root, f.path)
+			if err != nil {
+				path = f.path
+			}
+			table.SetCell(row, 0, tview.NewTableCell("  "+tview.Escape(path)).SetTextColor(color))
+			table.SetCell(row, 1, tview.NewTableCell(f.modTime.Format(dateFmt)).SetTextColor(color))
+			d.rows = append(d.rows, f)
+		}
+	}
+}
+
+// freed space which is freed by removing the marked files.
+// the content of hard links is freed only if all links to it are marked
+func (d *Duplicates) freed() int64 {
+	var freed int64
+	for _, g := range d.groups {
+		marked := make(map[string]bool)
+		kept := make(map[string]bool)
+		for _, f := range g.files {
+			if d.marked[f] {
+				marked[f.inode] = true
+			} else {
+				kept[f.inode] = true
+			}
+		}
+		for inode := range marked {
+			if !kept[inode] {
+				freed += g.size
+			}
+		}
+	}
+	return freed
+}
+
+// GetSelectFile get the selected file
+func (d *Duplicates) GetSelectFile() *dupFile {
+	row, _ := d.GetSelection()
+	if row < 0 || row >= len(d.rows) {
+		return nil
+	}
+	return d.rows[row]
+}
+
+// ToggleMark mark the selected file, or unmark it if it is marked
+func (d *Duplicates) ToggleMark() {
+	f := d.GetSelectFile()
+	if f == nil {
+		return
+	}
+	if d.marked[f] {
+		delete(d.marked, f)
+	} else {
+		d.marked[f] = true
+	}
+	d.UpdateView()
+}
+
+// MarkAllButOne mark all files of each group except the file which is kept
+func (d *Duplicates) MarkAllButOne(keep func(a, b *dupFile) bool) {
+	d.marked = make(map[*dupFile]bool)
+	for _, g := range d.groups {
+		kept := g.files[0]
+		for _, f := range g.files[1:] {
+			if keep(f, kept) {
+				kept = f
+			}
+		}
+		for _, f := range g.files {
+			if f != kept {
+				d.marked[f] = true
+			}
+		}
+	}
+	d.UpdateView()
+}
+
+func keepOldest(a, b *dupFile) bool {
+	return a.modTime.Before(b.modTime)
+}
+
+func keepShortestPath(a, b *dupFile) bool {
+	return len(a.path) < len(b.path)
+}
+
+// keepers unmarked file of each group, at least one copy must be kept
+func (d *Duplicates) keepers() (map[*dupGroup]*dupFile, error) {
+	keepers := make(map[*dupGroup]*dupFile)
+	for _, g := range d.groups {
+		for _, f := range g.files {
+			if !d.marked[f] {
+				keepers[g] = f
+				break
+			}
+		}
+		if keepers[g] == nil {
+			return nil, fmt.Errorf("%s: %s", ErrNoCopyLeft, g.files[0].path)
+		}
+	}
+	return keepers, nil
+}
+
+// apply apply the action to the marked files, and remove them from the groups.
+// files which have been changed since the scan are not touched
+func (d *Duplicates) apply(action func(f, keeper *dupFile) error) error {
+	keepers, err := d.keepers()
+	if err != nil {
+		return err
+	}
+
+	// groups are updated even if the action fails on the way
+	done := make(map[*dupFile]bool)
+	defer func() {
+		var groups []*dupGroup
+		for _, g := range d.groups {
+			var files []*dupFile
+			for _, f := range g.files {
+				if !done[f] {
+					files = append(files, f)
+				}
+			}
+			g.files = files
+			if g.Wasted() > 0 {
+				groups = append(groups, g)
+			}
+		}
+		d.groups = groups
+		d.marked = make(map[*dupFile]bool)
+		d.UpdateView()
+	}()
+
+	for _, g := range d.groups {
+		for _, f := range g.files {
+			if !d.marked[f] {
+				continue
+			}
+			if err := f.unchanged(); err != nil {
+				log.Println(err)
+				return err
+			}
+			if err := keepers[g].unchanged(); err != nil {
+				log.Println(err)
+				return err
+			}
+			if err := action(f, keepers[g]); err != nil {
+				log.Println(err)
+				return err
+			}
+			done[f] = true
+		}
+	}
+	return nil
+}
+
+// Remove remove the marked files after the confirmation
+func (d *Duplicates) Remove(gui *Gui) {
+	if len(d.marked) == 0 {
+		return
+	}
+	if _, err := d.keepers(); err != nil {
+		gui.Message(err.Error(), DuplicatePanel)
+		return
+	}
+
+	message := fmt.Sprintf("do you want to remove %d marked files?", len(d.marked))
+	gui.Confirm(message, "yes", DuplicatePanel, func() error {
+		return d.apply(func(f, keeper *dupFile) error {
+			return system.RemoveFile(f.path)
+		})
+	})
+}
+
+// Link replace the marked files with hard links to the kept copy after the confirmation
+func (d *Duplicates) Link(gui *Gui) {
+	if len(d.marked) == 0 {
+		return
+	}
+	if _, err := d.keepers(); err != nil {
+		gui.Message(err.Error(), DuplicatePanel)
+		return
+	}
+
+	message := fmt.Sprintf("do you want to replace %d marked files with hard links?", len(d.marked))
+	gui.Confirm(message, "yes", DuplicatePanel, func() error {
+		return d.apply(func(f, keeper *dupFile) error {
+			return system.ReplaceWithLink(keeper.path, f.path)
+		})
+	})
+}
+
+func (d *Duplicates) Keybinding(gui *Gui) {
+	d.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyF1:
+			gui.Help.UpdateView(DuplicatePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("duplicates")
+			return nil
+		case tcell.KeyEscape:
+			d.Close(gui)
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'q':
+			d.Close(gui)
+		case '?':
+			gui.Help.UpdateView(DuplicatePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("duplicates")
+		case ' ':
+			d.ToggleMark()
+		case 'o':
+			d.MarkAllButOne(keepOldest)
+		case 's':
+			d.MarkAllButOne(keepShortestPath)
+		case 'c':
+			d.marked = make(map[*dupFile]bool)
+			d.UpdateView()
+		case 'd':
+			d.Remove(gui)
+		case 'L':
+			d.Link(gui)
+		default:
+			return event
+		}
+		return nil
+	})
+}
@@ -0,0 +1,163 @@
+package gui
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dupNames relative paths of files in each group
+func dupNames(dir string, groups []*dupGroup) [][]string {
+	var names [][]string
+	for _, g := range groups {
+		var group []string
+		for _, f := range g.files {
+			rel, _ := filepath.Rel(dir, f.path)
+			group = append(group, rel)
+		}
+		sort.Strings(group)
+		names = append(names, group)
+	}
+	return names
+}
+
+func scanDuplicates(t *testing.T, dir string) *Duplicates {
+	t.Helper()
+	groups, err := findDuplicates(context.Background(), dir, &dupProgress{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDuplicates()
+	d.root = dir
+	d.groups = groups
+	return d
+}
+
+func TestFindDuplicates(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestContents(t, dir, map[string]string{
+		"a":       "large content",
+		"sub/a":   "large content",
+		"sub/b":   "large content",
+		"c":       "small",
+		"d":       "small",
+		"e":       "other",
+		"f":       "same size",
+		"g":       "not equal",
+		"empty1":  "",
+		"empty2":  "",
+		"linked1": "linked",
+	})
+	if err := os.Link(filepath.Join(dir, "linked1"), filepath.Join(dir, "linked2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "c"), filepath.Join(dir, "symlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	d := scanDuplicates(t, dir)
+	// hard links waste no space, and the largest waste is first
+	want := [][]string{{"a", "sub/a", "sub/b"}, {"c", "d"}}
+	if got := dupNames(dir, d.groups); !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if got := d.groups[0].Wasted(); got != 2*int64(len("large content")) {
+		t.Errorf("wasted = %d, want %d", got, 2*len("large content"))
+	}
+}
+
+func TestDuplicatesFreed(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestContents(t, dir, map[string]string{"a": "content", "b": "content"})
+	for _, name := range []string{"a2", "a3"} {
+		if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := scanDuplicates(t, dir)
+	files := make(map[string]*dupFile)
+	for _, f := range d.groups[0].files {
+		files[filepath.Base(f.path)] = f
+	}
+	size := int64(len("content"))
+
+	tests := []struct {
+		marked []string
+		want   int64
+	}{
+		{[]string{"b"}, size},
+		// the content is still linked from a
+		{[]string{"a2", "a3"}, 0},
+		{[]string{"a", "a2", "a3"}, size},
+	}
+	for _, tt := range tests {
+		d.marked = make(map[*dupFile]bool)
+		for _, name := range tt.marked {
+			d.marked[files[name]] = true
+		}
+		if got := d.freed(); got != tt.want {
+			t.Errorf("freed with %v marked = %d, want %d", tt.marked, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicatesApply(t *testing.T) {
+	remove := func(f, keeper *dupFile) error {
+		return os.Remove(f.path)
+	}
+
+	t.Run("remove", func(t *testing.T) {
+		dir := testDir(t)
+		defer os.RemoveAll(dir)
+		writeTestContents(t, dir, map[string]string{"a": "content", "b": "content", "c": "content"})
+
+		d := scanDuplicates(t, dir)
+		d.MarkAllButOne(keepShortestPath)
+		if err := d.apply(remove); err != nil {
+			t.Fatal(err)
+		}
+		if len(d.groups) != 0 {
+			t.Errorf("groups = %v, want none", dupNames(dir, d.groups))
+		}
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("%d files are left, want 1", len(entries))
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		dir := testDir(t)
+		defer os.RemoveAll(dir)
+		writeTestContents(t, dir, map[string]string{"a": "content", "b": "content"})
+
+		d := scanDuplicates(t, dir)
+		d.MarkAllButOne(keepShortestPath)
+		// the keeper is changed after the scan
+		later := time.Now().Add(time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, "a"), later, later); err != nil {
+			t.Fatal(err)
+		}
+
+		err := d.apply(remove)
+		if err == nil || !strings.HasPrefix(err.Error(), ErrDupChanged.Error()) {
+			t.Fatalf("err = %v, want %v", err, ErrDupChanged)
+		}
+		for _, name := range []string{"a", "b"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("%s is removed", name)
+			}
+		}
+	})
+}
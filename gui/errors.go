@@ -12,4 +12,24 @@ var (
 	ErrNoPathName   = errors.New("no path name")
 	ErrNotExistPath = errors.New("not exist path")
 	ErrNoEditor     = errors.New("$EDITOR is empty")
+	ErrNotSupported = errors.New("not supported on s3")
+	ErrNotInTree    = errors.New("not supported in tree mode")
+	ErrNoDiffFiles  = errors.New("mark one or two files to diff")
+	ErrDiffBinary   = errors.New("can't diff binary files")
+	ErrNotFile      = errors.New("not a file")
+	ErrSymlinkLoop  = errors.New("symlink loop")
+	ErrInvalidMode  = errors.New("invalid mode, use octal like 0644")
+	ErrNoCopyLeft   = errors.New("all copies are marked")
+	ErrDupChanged   = errors.New("file was changed after the scan")
+	ErrNotDir       = errors.New("not a directory")
+	ErrNestedDirs   = errors.New("directories contain each other")
+	ErrNoBookmark   = errors.New("no bookmark for the shortcut")
+	ErrShortcutUsed = errors.New("shortcut is used by")
+	ErrInvalidMark  = errors.New("mark must be a letter")
+	ErrNoMark       = errors.New("no mark")
+	ErrNoMarks      = errors.New("no marks, set with m and a letter")
+
+	ErrRenameLines    = errors.New("number of lines was changed")
+	ErrRenameConflict = errors.New("same name is used twice")
+	ErrRenameRestore  = errors.New("can't restore the original names")
 )
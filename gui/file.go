@@ -1,14 +1,17 @@
 package gui
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
+	"os"
 	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 
+	"github.com/skanehira/ff/s3"
 	"gopkg.in/djherbis/times.v1"
 )
 
@@ -26,9 +29,24 @@ type File struct {
 	Group      string
 	Viewable   bool
 	IsDir      bool
+	IsLink     bool
+	LinkTarget string
+	BrokenLink bool
 }
 
-func GetFiles(path, searchWord string, ignorecase, showHidden bool) []*File {
+func matchName(name, word string, ignorecase bool) bool {
+	if ignorecase {
+		name = strings.ToLower(name)
+		word = strings.ToLower(word)
+	}
+	return strings.Contains(name, word)
+}
+
+func GetFiles(path, searchWord string, ignorecase, showHidden, hideIgnored bool) []*File {
+	if s3.IsPath(path) {
+		return GetS3Files(path, searchWord, ignorecase, showHidden, hideIgnored)
+	}
+
 	var files []*File
 
 	entries, err := ioutil.ReadDir(path)
@@ -41,6 +59,15 @@ func GetFiles(path, searchWord string, ignorecase, showHidden bool) []*File {
 		return nil
 	}
 
+	var names []string
+	for _, file := range entries {
+		names = append(names, file.Name())
+	}
+	var ignored map[string]bool
+	if hideIgnored {
+		ignored = ignores.Ignored(context.Background(), path, names)
+	}
+
 	var access, change, create,
 		perm, owner, group string
 
@@ -48,20 +75,19 @@ func GetFiles(path, searchWord string, ignorecase, showHidden bool) []*File {
 		if !showHidden && file.Name()[0] == '.' {
 			continue
 		}
-		var name, word string
-		if ignorecase {
-			name = strings.ToLower(file.Name())
-			word = strings.ToLower(searchWord)
-		} else {
-			name = file.Name()
-			word = searchWord
+		if ignored[file.Name()] {
+			continue
 		}
-		if !strings.Contains(name, word) {
+		if !matchName(file.Name(), searchWord, ignorecase) {
 			continue
 		}
 		// get file times
 		pathName := filepath.Join(path, file.Name())
 		t, err := times.Stat(pathName)
+		// broken symlink
+		if err != nil && file.Mode()&os.ModeSymlink != 0 {
+			t, err = times.Lstat(pathName)
+		}
 		if err != nil {
 			log.Printf("%s: %s\n", ErrGetTime, err)
 			continue
@@ -93,6 +119,21 @@ func GetFiles(path, searchWord string, ignorecase, showHidden bool) []*File {
 			}
 		}
 
+		// symlink to directory can be entered if followSymlinks is true
+		isDir := file.IsDir()
+		var isLink, broken bool
+		var linkTarget string
+		if file.Mode()&os.ModeSymlink != 0 {
+			isLink = true
+			linkTarget, _ = os.Readlink(pathName)
+			target, err := os.Stat(pathName)
+			if err != nil {
+				broken = true
+			} else if followSymlinks {
+				isDir = target.IsDir()
+			}
+		}
+
 		// create entriey
 		files = append(files, &File{
 			Name:       file.Name(),
@@ -101,7 +142,10 @@ func GetFiles(path, searchWord string, ignorecase, showHidden bool) []*File {
 			Change:     change,
 			Size:       file.Size(),
 			Permission: perm,
-			IsDir:      file.IsDir(),
+			IsDir:      isDir,
+			IsLink:     isLink,
+			LinkTarget: linkTarget,
+			BrokenLink: broken,
 			Owner:      owner,
 			Group:      group,
 			PathName:   pathName,
@@ -2,14 +2,13 @@ package gui
 
 import (
 	"os"
-	"path/filepath"
 
 	"log"
 
-	"github.com/dustin/go-humanize"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	"github.com/skanehira/ff/system"
+	"github.com/skanehira/ff/git"
+	"github.com/skanehira/ff/s3"
 )
 
 var (
@@ -25,9 +24,13 @@ type selectPos struct {
 type FileTable struct {
 	enableIgnorecase bool
 	showHidden       bool
+	hideIgnored      bool
 	files            []*File
+	path             string
 	selectPos        map[string]selectPos
 	searchWord       string
+	gitStatus        *git.Status
+	sortSize         bool
 	*tview.Table
 }
 
@@ -76,16 +79,18 @@ func (e *FileTable) RestorePos(path string) {
 
 // SetEntries set entries
 func (e *FileTable) SetEntries(path string) []*File {
-	files := GetFiles(path, e.searchWord, e.enableIgnorecase, e.showHidden)
+	files := GetFiles(path, e.searchWord, e.enableIgnorecase, e.showHidden, e.hideIgnored)
 
+	// the selected entry must be taken before the entries are replaced
+	selected := e.GetSelectEntry()
 	if len(files) == 0 {
 		e.files = nil
-		e.SetColumns()
+		e.setColumns(selected)
 		return nil
 	}
 
 	e.files = files
-	e.SetColumns()
+	e.setColumns(selected)
 	return files
 }
 
@@ -99,15 +104,43 @@ func (e *FileTable) SetViewable(viewable bool) {
 	e.RefreshView()
 }
 
+// SetGitStatus set git status of entries, nil if the directory is not in git repository
+func (e *FileTable) SetGitStatus(status *git.Status) {
+	e.gitStatus = status
+	e.SetColumns()
+}
+
+func (e *FileTable) SetShowHidden(show bool) {
+	e.showHidden = show
+}
+
+func (e *FileTable) SetHideIgnored(hide bool) {
+	e.hideIgnored = hide
+}
+
+// SelectEntry select the entry which has the path
+func (e *FileTable) SelectEntry(pathName string) {
+	for i, f := range e.files {
+		if f.PathName == pathName {
+			e.Select(i+1, 0)
+			return
+		}
+	}
+}
+
 // SetHeader set table header
 func (e *FileTable) SetHeader() {
 	headers := []string{
 		"Name",
 		"Size",
+		"Modified",
 		"Permission",
 		"Owner",
 		"Group",
 	}
+	if e.gitStatus != nil {
+		headers = append(headers, "Git")
+	}
 	for k, v := range headers {
 		e.Table.SetCell(0, k, &tview.TableCell{
 			Text:            v,
@@ -121,19 +154,34 @@ func (e *FileTable) SetHeader() {
 
 // SetColumns set entries
 func (e *FileTable) SetColumns() {
+	e.setColumns(e.GetSelectEntry())
+}
+
+// setColumns set entries, and keep the selected entry when entries are sorted by size
+func (e *FileTable) setColumns(selected *File) {
+	if e.sortSize {
+		sortBySize(e.files)
+	}
+
 	table := e.Clear()
 	e.SetHeader()
 	var i int
 	for _, entry := range e.files {
-		size := humanize.Bytes(uint64(entry.Size))
-		table.SetCell(i+1, 0, tview.NewTableCell(entry.Name))
-		table.SetCell(i+1, 1, tview.NewTableCell(size))
-		table.SetCell(i+1, 2, tview.NewTableCell(entry.Permission))
-		table.SetCell(i+1, 3, tview.NewTableCell(entry.Owner))
-		table.SetCell(i+1, 4, tview.NewTableCell(entry.Group))
+		table.SetCell(i+1, 0, tview.NewTableCell(displayName(entry)))
+		table.SetCell(i+1, 1, tview.NewTableCell(displaySize(entry)))
+		table.SetCell(i+1, 2, tview.NewTableCell(entry.Change))
+		table.SetCell(i+1, 3, tview.NewTableCell(entry.Permission))
+		table.SetCell(i+1, 4, tview.NewTableCell(entry.Owner))
+		table.SetCell(i+1, 5, tview.NewTableCell(entry.Group))
+		if e.gitStatus != nil {
+			table.SetCell(i+1, 6, tview.NewTableCell(e.gitStatus.Get(entry.PathName).String()))
+		}
 		i++
 	}
 
+	if e.sortSize && selected != nil {
+		e.SelectEntry(selected.PathName)
+	}
 	e.UpdateColor()
 }
 
@@ -155,29 +203,42 @@ func (e *FileTable) GetSelectEntry() *File {
 
 func (e *FileTable) UpdateColor() {
 	rowNum := e.GetRowCount()
+	colNum := e.GetColumnCount()
 
 	e.GetSelection()
 	for i := 1; i < rowNum; i++ {
 		color := tcell.ColorWhite
-		if e.files[i-1].IsDir {
+		switch f := e.files[i-1]; {
+		case f.BrokenLink:
+			color = brokenLinkColor
+		case f.IsLink:
+			color = linkColor
+		case f.IsDir:
 			color = tcell.ColorDarkCyan
 		}
+		var status git.FileStatus
+		if e.gitStatus != nil {
+			status = e.gitStatus.Get(e.files[i-1].PathName)
+		}
+		if status == git.Ignored {
+			color = gitColor(status)
+		}
+		if marks.Has(e.files[i-1].PathName) {
+			color = markColor
+		}
 
-		for j := 0; j < 5; j++ {
+		for j := 0; j < colNum; j++ {
 			e.GetCell(i, j).SetTextColor(color)
 		}
+		if e.gitStatus != nil {
+			e.GetCell(i, colNum-1).SetTextColor(gitColor(status))
+		}
 	}
 
 }
 
 func (e *FileTable) UpdateView() {
-	current, err := os.Getwd()
-	if err != nil {
-		log.Println(err)
-		return
-	}
-
-	e.SetEntries(current)
+	e.SetEntries(e.path)
 }
 
 func (e *FileTable) ChangeDir(gui *Gui, current, target string) error {
@@ -206,27 +267,46 @@ func (e *FileTable) ChangeDir(gui *Gui, current, target string) error {
 		gui.Preview.UpdateView(gui, entry)
 	}
 
-	if err := os.Chdir(target); err != nil {
-		log.Println(err)
-		return err
+	// s3 path is not a local directory
+	if !s3.IsPath(target) {
+		if err := os.Chdir(target); err != nil {
+			log.Println(err)
+			return err
+		}
 	}
+	e.path = target
 
 	// restore select position
 	e.RestorePos(target)
 
 	gui.InputPath.SetText(target)
+	gui.RefreshGitStatus()
+	gui.RefreshDirSizes()
 
 	return nil
 }
 
 func (e *FileTable) Keybinding(gui *Gui) {
 	e.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if gui.prefixKeybinding(event) {
+			return nil
+		}
 		gui.commonFileBrowserKeybinding(event)
 
 		switch event.Key() {
 		case tcell.KeyF1:
 			gui.Help.UpdateView(FileTablePanel)
 			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("main")
+
+		// sort by size, or by name
+		case tcell.KeyCtrlS:
+			e.sortSize = !e.sortSize
+			if e.sortSize {
+				e.SetTitle("files (size)")
+			} else {
+				e.SetTitle("files")
+			}
+			e.UpdateView()
 		}
 
 		switch event.Rune() {
@@ -236,7 +316,7 @@ func (e *FileTable) Keybinding(gui *Gui) {
 
 		case 'h':
 			current := gui.InputPath.GetText()
-			parent := filepath.Dir(current)
+			parent := parentPath(current)
 
 			if parent != "" {
 				if err := e.ChangeDir(gui, current, parent); err != nil {
@@ -266,16 +346,9 @@ func (e *FileTable) Keybinding(gui *Gui) {
 					return nil
 				}
 
-				if entry.IsDir {
-					if err := system.RemoveDirAll(entry.PathName); err != nil {
-						log.Println(err)
-						return err
-					}
-				} else {
-					if err := system.RemoveFile(entry.PathName); err != nil {
-						log.Println(err)
-						return err
-					}
+				if err := removeEntry(entry); err != nil {
+					log.Println(err)
+					return err
 				}
 
 				path := gui.InputPath.GetText()
@@ -295,7 +368,7 @@ func (e *FileTable) Keybinding(gui *Gui) {
 			gui.Register.MoveSource = nil
 
 			row, _ := e.GetSelection()
-			for i := 0; i < 5; i++ {
+			for i := 0; i < e.GetColumnCount(); i++ {
 				e.GetCell(row, i).SetTextColor(tcell.ColorYellow)
 			}
 
@@ -311,7 +384,7 @@ func (e *FileTable) Keybinding(gui *Gui) {
 			gui.Register.MoveSource = entry
 
 			row, _ := e.GetSelection()
-			for i := 0; i < 5; i++ {
+			for i := 0; i < e.GetColumnCount(); i++ {
 				e.GetCell(row, i).SetTextColor(tcell.ColorYellow)
 			}
 
@@ -327,8 +400,8 @@ func (e *FileTable) Keybinding(gui *Gui) {
 							return ErrNoNewName
 						}
 
-						target := filepath.Join(gui.InputPath.GetText(), name)
-						if err := system.Copy(source.PathName, target); err != nil {
+						target := joinPath(gui.InputPath.GetText(), name)
+						if err := copyEntry(source, target); err != nil {
 							log.Println(err)
 							return err
 						}
@@ -351,8 +424,8 @@ func (e *FileTable) Keybinding(gui *Gui) {
 
 						current := gui.InputPath.GetText()
 
-						target := filepath.Join(current, name)
-						if err := system.Rename(source.PathName, target); err != nil {
+						target := joinPath(current, name)
+						if err := rename(source.PathName, target); err != nil {
 							return err
 						}
 
@@ -362,7 +435,7 @@ func (e *FileTable) Keybinding(gui *Gui) {
 					})
 			}
 
-		case 'm':
+		case 'N':
 			gui.Form(map[string]string{"name": ""}, "create", "new direcotry",
 				"create_directory", FileTablePanel,
 				7, func(values map[string]string) error {
@@ -371,8 +444,8 @@ func (e *FileTable) Keybinding(gui *Gui) {
 						return ErrNoDirName
 					}
 
-					target := filepath.Join(gui.InputPath.GetText(), name)
-					if err := system.NewDir(target); err != nil {
+					target := joinPath(gui.InputPath.GetText(), name)
+					if err := newDir(target, gui.Config.DirMode); err != nil {
 						log.Println(err)
 						return err
 					}
@@ -396,8 +469,8 @@ func (e *FileTable) Keybinding(gui *Gui) {
 
 					current := gui.InputPath.GetText()
 
-					target := filepath.Join(current, name)
-					if err := system.Rename(entry.PathName, target); err != nil {
+					target := joinPath(current, name)
+					if err := rename(entry.PathName, target); err != nil {
 						return err
 					}
 
@@ -413,8 +486,8 @@ func (e *FileTable) Keybinding(gui *Gui) {
 						return ErrNoFileOrDirName
 					}
 
-					target := filepath.Join(gui.InputPath.GetText(), name)
-					if err := system.NewFile(target); err != nil {
+					target := joinPath(gui.InputPath.GetText(), name)
+					if err := newFile(target, gui.Config.FileMode); err != nil {
 						log.Println(err)
 						return err
 					}
@@ -426,6 +499,20 @@ func (e *FileTable) Keybinding(gui *Gui) {
 		case 'f', '/':
 			e.SearchFiles(gui)
 
+		case 'u':
+			if err := gui.ComputeDirSizes(); err != nil {
+				gui.Message(err.Error(), FileTablePanel)
+			}
+
+		// mark file
+		case ' ':
+			entry := e.GetSelectEntry()
+			if entry == nil {
+				return event
+			}
+			marks.Toggle(entry)
+			e.UpdateColor()
+
 		}
 		return event
 	})
@@ -3,21 +3,25 @@ package gui
 import (
 	"log"
 	"os"
-	"path/filepath"
+	"path"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	"github.com/skanehira/ff/system"
+	"github.com/skanehira/ff/git"
+	"github.com/skanehira/ff/s3"
 )
 
 type Tree struct {
-	files      []*File
-	ignorecase bool
-	showHidden bool
-	searchWord string
-	selectPos  map[string]string
-	expandInfo map[string]struct{}
-	originRoot *tview.TreeNode
+	files       []*File
+	path        string
+	ignorecase  bool
+	showHidden  bool
+	hideIgnored bool
+	searchWord  string
+	selectPos   map[string]string
+	expandInfo  map[string]struct{}
+	originRoot  *tview.TreeNode
+	gitStatus   *git.Status
 	*tview.TreeView
 }
 
@@ -122,11 +126,7 @@ func (t *Tree) GetCurrentlyNode(oldpath string, target *tview.TreeNode) *tview.T
 }
 
 func (t *Tree) UpdateView() {
-	current, err := os.Getwd()
-	if err != nil {
-		log.Println(err)
-		return
-	}
+	current := t.path
 
 	t.SetSelectPos(current)
 	t.SetEntries(current)
@@ -152,7 +152,7 @@ func (t *Tree) ChangeDir(gui *Gui, current string, target string) error {
 	}
 	t.SetSelectPos(current)
 
-	root := tview.NewTreeNode(filepath.Base(target)).
+	root := tview.NewTreeNode(path.Base(target)).
 		SetReference(&File{PathName: current, IsDir: true}).SetSelectable(false)
 
 	t.SetRoot(root).SetCurrentNode(root)
@@ -161,19 +161,53 @@ func (t *Tree) ChangeDir(gui *Gui, current string, target string) error {
 
 	t.SetEntries(target)
 
-	if err := os.Chdir(target); err != nil {
-		log.Println(err)
-		return err
+	// s3 path is not a local directory
+	if !s3.IsPath(target) {
+		if err := os.Chdir(target); err != nil {
+			log.Println(err)
+			return err
+		}
 	}
+	t.path = target
 
 	t.RestorePos(target)
 
 	gui.InputPath.SetText(target)
+	gui.RefreshGitStatus()
 	return nil
 }
 
+// SetGitStatus set git status of nodes, nil if the directory is not in git repository
+func (t *Tree) SetGitStatus(status *git.Status) {
+	t.gitStatus = status
+	t.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		if f, ok := node.GetReference().(*File); ok && parent != nil {
+			node.SetText(t.nodeText(f)).SetColor(t.nodeColor(f))
+		}
+		return true
+	})
+}
+
+func (t *Tree) SetShowHidden(show bool) {
+	t.showHidden = show
+}
+
+func (t *Tree) SetHideIgnored(hide bool) {
+	t.hideIgnored = hide
+}
+
+// SelectEntry select the node which has the path
+func (t *Tree) SelectEntry(pathName string) {
+	if node := t.GetCurrentlyNode(pathName, t.GetRoot()); node != nil {
+		t.SetCurrentNode(node)
+	}
+}
+
 func (t *Tree) Keybinding(gui *Gui) {
 	t.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if gui.prefixKeybinding(event) {
+			return nil
+		}
 		gui.commonFileBrowserKeybinding(event)
 
 		switch event.Key() {
@@ -189,7 +223,7 @@ func (t *Tree) Keybinding(gui *Gui) {
 
 		case 'H':
 			current := gui.InputPath.GetText()
-			t.ChangeDir(gui, current, filepath.Dir(current))
+			t.ChangeDir(gui, current, parentPath(current))
 
 		case 'L':
 			f := t.GetSelectEntry()
@@ -208,7 +242,11 @@ func (t *Tree) Keybinding(gui *Gui) {
 			node := t.GetCurrentNode()
 			f := t.GetSelectEntry()
 			if f != nil && f.IsDir {
-				files := GetFiles(f.PathName, t.searchWord, t.ignorecase, t.showHidden)
+				if f.IsLink && isSymlinkLoop(f.PathName) {
+					gui.Message(ErrSymlinkLoop.Error(), FileTreePanel)
+					return event
+				}
+				files := GetFiles(f.PathName, t.searchWord, t.ignorecase, t.showHidden, t.hideIgnored)
 				t.AddNode(node, files)
 				node.Expand()
 				t.expandInfo[f.PathName] = struct{}{}
@@ -221,16 +259,9 @@ func (t *Tree) Keybinding(gui *Gui) {
 					return nil
 				}
 
-				if entry.IsDir {
-					if err := system.RemoveDirAll(entry.PathName); err != nil {
-						log.Println(err)
-						return err
-					}
-				} else {
-					if err := system.RemoveFile(entry.PathName); err != nil {
-						log.Println(err)
-						return err
-					}
+				if err := removeEntry(entry); err != nil {
+					log.Println(err)
+					return err
 				}
 
 				t.UpdateView()
@@ -274,11 +305,11 @@ func (t *Tree) Keybinding(gui *Gui) {
 							if e.IsDir {
 								current = e.PathName
 							} else {
-								current = filepath.Dir(e.PathName)
+								current = parentPath(e.PathName)
 							}
 						}
-						target := filepath.Join(current, name)
-						if err := system.Copy(source.PathName, target); err != nil {
+						target := joinPath(current, name)
+						if err := copyEntry(source, target); err != nil {
 							log.Println(err)
 							return err
 						}
@@ -301,8 +332,8 @@ func (t *Tree) Keybinding(gui *Gui) {
 
 						current := gui.InputPath.GetText()
 
-						target := filepath.Join(current, name)
-						if err := system.Rename(source.PathName, target); err != nil {
+						target := joinPath(current, name)
+						if err := rename(source.PathName, target); err != nil {
 							return err
 						}
 
@@ -312,7 +343,7 @@ func (t *Tree) Keybinding(gui *Gui) {
 					})
 			}
 
-		case 'm':
+		case 'N':
 			gui.Form(map[string]string{"name": ""}, "create", "new direcotry",
 				"create_directory", FileTreePanel,
 				7, func(values map[string]string) error {
@@ -328,11 +359,11 @@ func (t *Tree) Keybinding(gui *Gui) {
 						if e.IsDir {
 							current = e.PathName
 						} else {
-							current = filepath.Dir(e.PathName)
+							current = parentPath(e.PathName)
 						}
 					}
-					target := filepath.Join(current, name)
-					if err := system.NewDir(target); err != nil {
+					target := joinPath(current, name)
+					if err := newDir(target, gui.Config.DirMode); err != nil {
 						log.Println(err)
 						return err
 					}
@@ -358,11 +389,11 @@ func (t *Tree) Keybinding(gui *Gui) {
 
 					e := t.GetSelectEntry()
 					if e != nil {
-						current = filepath.Dir(e.PathName)
+						current = parentPath(e.PathName)
 					}
-					target := filepath.Join(current, name)
+					target := joinPath(current, name)
 
-					if err := system.Rename(entry.PathName, target); err != nil {
+					if err := rename(entry.PathName, target); err != nil {
 						return err
 					}
 
@@ -385,12 +416,12 @@ func (t *Tree) Keybinding(gui *Gui) {
 						if e.IsDir {
 							current = e.PathName
 						} else {
-							current = filepath.Dir(e.PathName)
+							current = parentPath(e.PathName)
 						}
 					}
 
-					target := filepath.Join(current, name)
-					if err := system.NewFile(target); err != nil {
+					target := joinPath(current, name)
+					if err := newFile(target, gui.Config.FileMode); err != nil {
 						log.Println(err)
 						return err
 					}
@@ -401,6 +432,20 @@ func (t *Tree) Keybinding(gui *Gui) {
 		case 'f', '/':
 			t.SearchFiles(gui)
 			t.UpdateView()
+
+		case 'u':
+			if err := gui.ComputeDirSizes(); err != nil {
+				gui.Message(err.Error(), FileTreePanel)
+			}
+
+		// mark file
+		case ' ':
+			entry := t.GetSelectEntry()
+			if entry == nil {
+				return event
+			}
+			marks.Toggle(entry)
+			t.GetCurrentNode().SetColor(t.nodeColor(entry))
 		}
 
 		return event
@@ -421,7 +466,7 @@ func (t *Tree) Keybinding(gui *Gui) {
 }
 
 func (t *Tree) SetEntries(path string) []*File {
-	files := GetFiles(path, t.searchWord, t.ignorecase, t.showHidden)
+	files := GetFiles(path, t.searchWord, t.ignorecase, t.showHidden, t.hideIgnored)
 
 	if len(files) == 0 {
 		return nil
@@ -433,6 +478,38 @@ func (t *Tree) SetEntries(path string) []*File {
 	return files
 }
 
+func (t *Tree) gitStatusOf(f *File) git.FileStatus {
+	if t.gitStatus == nil {
+		return git.Unmodified
+	}
+	return t.gitStatus.Get(f.PathName)
+}
+
+// nodeText file name with symlink target and git status
+func (t *Tree) nodeText(f *File) string {
+	if status := t.gitStatusOf(f); status != git.Unmodified {
+		return displayName(f) + " " + status.String()
+	}
+	return displayName(f)
+}
+
+func (t *Tree) nodeColor(f *File) tcell.Color {
+	status := t.gitStatusOf(f)
+	switch {
+	case marks.Has(f.PathName):
+		return markColor
+	case status != git.Unmodified:
+		return gitColor(status)
+	case f.BrokenLink:
+		return brokenLinkColor
+	case f.IsLink:
+		return linkColor
+	case f.IsDir:
+		return tcell.ColorDarkCyan
+	}
+	return tcell.ColorWhite
+}
+
 func (t *Tree) AddNode(parent *tview.TreeNode, files []*File) {
 	filesLen := len(files)
 	if filesLen == 0 {
@@ -441,12 +518,9 @@ func (t *Tree) AddNode(parent *tview.TreeNode, files []*File) {
 
 	nodes := make([]*tview.TreeNode, filesLen)
 	for i, f := range files {
-		n := tview.NewTreeNode(f.Name).SetReference(f)
-		if f.IsDir {
-			n.SetColor(tcell.ColorDarkCyan)
-		}
-		if _, ok := t.expandInfo[f.PathName]; ok {
-			files := GetFiles(f.PathName, t.searchWord, t.ignorecase, t.showHidden)
+		n := tview.NewTreeNode(t.nodeText(f)).SetReference(f).SetColor(t.nodeColor(f))
+		if _, ok := t.expandInfo[f.PathName]; ok && !(f.IsLink && isSymlinkLoop(f.PathName)) {
+			files := GetFiles(f.PathName, t.searchWord, t.ignorecase, t.showHidden, t.hideIgnored)
 			if len(files) != 0 {
 				t.AddNode(n, files)
 			}
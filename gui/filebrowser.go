@@ -1,6 +1,9 @@
 package gui
 
-import "github.com/rivo/tview"
+import (
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/git"
+)
 
 type FileBrowser interface {
 	tview.Primitive
@@ -12,4 +15,8 @@ type FileBrowser interface {
 	SetEntries(path string) []*File
 	ChangeDir(gui *Gui, current, target string) error
 	Keybinding(gui *Gui)
+	SetGitStatus(status *git.Status)
+	SetShowHidden(show bool)
+	SetHideIgnored(hide bool)
+	SelectEntry(pathName string)
 }
@@ -0,0 +1,253 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/git"
+	"github.com/skanehira/ff/s3"
+)
+
+// large repository may take a while
+const gitStatusTimeout = 10 * time.Second
+
+func gitColor(status git.FileStatus) tcell.Color {
+	switch status {
+	case git.Ignored:
+		return tcell.ColorGray
+	case git.Untracked:
+		return tcell.ColorOrange
+	case git.Staged:
+		return tcell.ColorGreen
+	case git.Modified:
+		return tcell.ColorYellow
+	case git.Conflicted:
+		return tcell.ColorRed
+	}
+	return tcell.ColorWhite
+}
+
+// RefreshGitStatus get git status of the current directory in background,
+// and decorate the file browser and the branch info
+func (gui *Gui) RefreshGitStatus() {
+	if !gui.Config.Git.Enable {
+		return
+	}
+
+	if gui.gitCancel != nil {
+		gui.gitCancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitStatusTimeout)
+	gui.gitCancel = cancel
+
+	dir := gui.InputPath.GetText()
+	if s3.IsPath(dir) {
+		gui.FileBrowser.SetGitStatus(nil)
+		gui.GitBranch.SetText("")
+		return
+	}
+
+	go func() {
+		status, err := git.GetStatus(ctx, dir)
+		if err != nil && err != git.ErrNotRepo {
+			log.Println(err)
+		}
+		if ctx.Err() == context.Canceled {
+			return
+		}
+
+		gui.App.QueueUpdateDraw(func() {
+			// the directory has been changed
+			if ctx.Err() == context.Canceled || dir != gui.InputPath.GetText() {
+				return
+			}
+
+			gui.FileBrowser.SetGitStatus(status)
+			if status == nil {
+				gui.GitBranch.SetText("")
+				return
+			}
+
+			info := fmt.Sprintf("[green]%s[-]", status.Branch)
+			if status.Ahead > 0 {
+				info += fmt.Sprintf(" ↑%d", status.Ahead)
+			}
+			if status.Behind > 0 {
+				info += fmt.Sprintf(" ↓%d", status.Behind)
+			}
+			gui.GitBranch.SetText(info)
+		})
+	}()
+}
+
+// colorDiff color lines of unified diff
+func colorDiff(diff string) string {
+	var buf strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		escaped := tview.Escape(line)
+		switch {
+		case strings.HasPrefix(line, "diff "), strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			buf.WriteString("[::b]" + escaped + "[::-]\n")
+		case strings.HasPrefix(line, "@@"):
+			buf.WriteString("[aqua]" + escaped + "[-]\n")
+		case strings.HasPrefix(line, "+"):
+			buf.WriteString("[green]" + escaped + "[-]\n")
+		case strings.HasPrefix(line, "-"):
+			buf.WriteString("[red]" + escaped + "[-]\n")
+		default:
+			buf.WriteString(escaped + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// gitAction run the action for the targets and refresh the status
+func (gui *Gui) gitAction(action func(path string) error) error {
+	defer func() {
+		gui.FileBrowser.UpdateView()
+		gui.RefreshGitStatus()
+	}()
+
+	for _, f := range gui.selectedFiles() {
+		if err := action(f.PathName); err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GitMenu open the menu of git actions for the selected or marked files
+func (gui *Gui) GitMenu() {
+	targets := gui.selectedFiles()
+	if len(targets) == 0 {
+		return
+	}
+	for _, f := range targets {
+		if s3.IsPath(f.PathName) {
+			gui.Message(ErrNotSupported.Error(), FileTablePanel)
+			return
+		}
+	}
+
+	pageName := "git"
+	closeMenu := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+	run := func(action func(path string) error) func() {
+		return func() {
+			closeMenu()
+			if err := gui.gitAction(action); err != nil {
+				gui.Message(err.Error(), FileTablePanel)
+			}
+		}
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.AddItem("stage", "", 'a', run(git.Stage)).
+		AddItem("unstage", "", 'u', run(git.Unstage)).
+		AddItem("discard changes", "", 'd', func() {
+			closeMenu()
+			gui.Confirm("do you want to discard changes?", "yes", FileTablePanel, func() error {
+				return gui.gitAction(git.Discard)
+			})
+		}).
+		AddItem("log", "", 'l', func() {
+			closeMenu()
+			gui.GitLog(targets[0])
+		})
+	if gui.Config.Preview.Enable {
+		list.AddItem("toggle diff preview", "", 'p', func() {
+			closeMenu()
+			gui.Preview.ToggleGitDiff(gui, gui.FileBrowser.GetSelectEntry())
+		})
+	}
+
+	list.SetBorder(true).SetTitle(filesTitle("git", targets)).SetTitleAlign(tview.AlignLeft)
+	list.SetDoneFunc(closeMenu)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'q' {
+			closeMenu()
+			return nil
+		}
+		return event
+	})
+
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(list, 40, list.GetItemCount()+2), true).ShowPage("main")
+}
+
+// GitLog show commits of the file, and show the content at the selected commit in the pager
+func (gui *Gui) GitLog(entry *File) {
+	if entry.IsDir {
+		gui.Message(ErrNotFile.Error(), FileTablePanel)
+		return
+	}
+
+	commits, err := git.Log(entry.PathName)
+	if err != nil {
+		log.Println(err)
+		gui.Message(err.Error(), FileTablePanel)
+		return
+	}
+
+	pageName := "git_log"
+	closeLog := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+
+	table := tview.NewTable().Select(1, 0).SetFixed(1, 1).SetSelectable(true, false)
+	table.SetBorder(true).SetTitle("log: " + entry.Name).SetTitleAlign(tview.AlignLeft)
+	for i, h := range []string{"Hash", "Date", "Author", "Subject"} {
+		table.SetCell(0, i, &tview.TableCell{
+			Text:            h,
+			NotSelectable:   true,
+			Align:           tview.AlignLeft,
+			Color:           tcell.ColorYellow,
+			BackgroundColor: tcell.ColorDefault,
+		})
+	}
+	for i, c := range commits {
+		table.SetCell(i+1, 0, tview.NewTableCell(c.Hash))
+		table.SetCell(i+1, 1, tview.NewTableCell(c.Date))
+		table.SetCell(i+1, 2, tview.NewTableCell(c.Author))
+		table.SetCell(i+1, 3, tview.NewTableCell(c.Subject))
+	}
+
+	table.SetSelectedFunc(func(row, col int) {
+		if row < 1 || row > len(commits) {
+			return
+		}
+		c := commits[row-1]
+		b, err := git.Show(c, entry.PathName)
+		if err != nil {
+			log.Println(err)
+			gui.Message(err.Error(), FileTablePanel)
+			return
+		}
+
+		gui.Pages.RemovePage(pageName)
+		gui.Pager.Show(gui, fmt.Sprintf("%s@%s", entry.Name, c.Hash), gui.Pager.formatBlob(entry.Name, b))
+	})
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			closeLog()
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'q' {
+			closeLog()
+			return nil
+		}
+		return event
+	})
+
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(table, 0, 0), true).ShowPage("main")
+}
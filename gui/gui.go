@@ -10,8 +10,12 @@ import (
 	"os/exec"
 
 	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
 )
 
+// interval to update the progress of background tasks
+const progressInterval = 100 * time.Millisecond
+
 var (
 	searchFiles     *tview.InputField
 	searchBookmarks *tview.InputField
@@ -24,6 +28,10 @@ const (
 	FileTablePanel
 	FileTreePanel
 	BookmarkPanel
+	PagerPanel
+	UsagePanel
+	DuplicatePanel
+	ComparePanel
 )
 
 // Register copy/paste file resource
@@ -49,16 +57,27 @@ type Gui struct {
 	CurrentPanel   Panel
 	Config         Config
 	InputPath      *tview.InputField
+	GitBranch      *tview.TextView
 	Register       *Register
 	HistoryManager *HistoryManager
 	FileBrowser    FileBrowser
 	Preview        *Preview
+	Pager          *Pager
+	Usage          *Usage
+	Duplicates     *Duplicates
+	Compare        *Compare
 	Bookmark       *Bookmarks
+	DirMarks       *DirMarks
 	Help           *Help
 	App            *tview.Application
 	Pages          *tview.Pages
+	grid           *tview.Grid
 	wg             *sync.WaitGroup
 	ctxCancel      context.CancelFunc
+	gitCancel      context.CancelFunc
+	sizeCancel     context.CancelFunc
+	sizeDir        string
+	prefixKey      rune
 }
 
 // New create new gui
@@ -66,23 +85,34 @@ func New(config Config) *Gui {
 	gui := &Gui{
 		Config:         config,
 		InputPath:      tview.NewInputField().SetLabel("path").SetLabelWidth(5),
+		GitBranch:      tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight),
 		HistoryManager: NewHistoryManager(),
 		Help:           NewHelp(),
+		Pager:          NewPager(config.Preview, config.IgnoreCase),
+		Usage:          NewUsage(),
+		Duplicates:     NewDuplicates(),
+		Compare:        NewCompare(),
+		DirMarks:       NewDirMarks(config),
 		App:            tview.NewApplication(),
 		Register:       &Register{},
 		Pages:          tview.NewPages(),
 		wg:             &sync.WaitGroup{},
 	}
 
-	if gui.Config.Preview.Enable {
-		gui.Preview = NewPreview(config.Preview.Colorscheme)
-	}
-
-	if gui.Config.EnableTree {
-		gui.FileBrowser = NewTree(config.IgnoreCase, config.ShowHidden)
-	} else {
-		gui.FileBrowser = NewFileTable(config.IgnoreCase, config.ShowHidden)
-	}
+	s3Client = s3.New(s3.Config{
+		Endpoint:     config.S3.Endpoint,
+		Region:       config.S3.Region,
+		AccessKey:    config.S3.AccessKey,
+		SecretKey:    config.S3.SecretKey,
+		SessionToken: config.S3.SessionToken,
+	})
+	ignores = newIgnoreFilter(config.Ignore)
+	followSymlinks = config.FollowSymlinks
+
+	// preview can be enabled at runtime
+	gui.Preview = NewPreview(config.Preview, config.ShowHidden)
+	gui.Preview.SetHideIgnored(config.Ignore.Enable)
+	gui.FileBrowser = gui.newFileBrowser()
 
 	if gui.Config.Bookmark.Enable {
 		bookmark, err := NewBookmark(config)
@@ -120,6 +150,23 @@ func (gui *Gui) Stop() {
 	gui.App.Stop()
 }
 
+// panelPage name of the page which has the panel
+func panelPage(panel Panel) string {
+	switch panel {
+	case PagerPanel:
+		return "pager"
+	case BookmarkPanel:
+		return "bookmark"
+	case UsagePanel:
+		return "usage"
+	case DuplicatePanel:
+		return "duplicates"
+	case ComparePanel:
+		return "compare"
+	}
+	return "main"
+}
+
 func (gui *Gui) Message(message string, panel Panel) {
 	doneLabel := "ok"
 	modal := tview.NewModal().
@@ -130,29 +177,63 @@ func (gui *Gui) Message(message string, panel Panel) {
 			gui.FocusPanel(panel)
 		})
 
-	gui.Pages.AddAndSwitchToPage("message", gui.Modal(modal, 80, 29), true).ShowPage("main")
+	gui.Pages.AddAndSwitchToPage("message", gui.Modal(modal, 80, 29), true).ShowPage(panelPage(panel))
 }
 
+// Progress show the progress of the background task over the panel, the task can be canceled.
+// the text is updated periodically until stop is called
+func (gui *Gui) Progress(pageName string, panel Panel, cancel context.CancelFunc, text func() string) (stop func()) {
+	modal := tview.NewModal().
+		SetText(text()).
+		AddButtons([]string{"cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			cancel()
+			gui.Pages.RemovePage(pageName).ShowPage(panelPage(panel))
+			gui.FocusPanel(panel)
+		})
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(modal, 60, 29), true).ShowPage(panelPage(panel))
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(progressInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				text := text()
+				gui.App.QueueUpdateDraw(func() {
+					modal.SetText(text)
+				})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// Confirm show the message and call doneFunc in the event loop if it is confirmed
 func (gui *Gui) Confirm(message, doneLabel string, panel Panel, doneFunc func() error) {
 	modal := tview.NewModal().
 		SetText(message).
 		AddButtons([]string{doneLabel, "cancel"}).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			gui.Pages.RemovePage("message").SwitchToPage("main")
+			gui.Pages.RemovePage("confirm").SwitchToPage(panelPage(panel))
+			gui.FocusPanel(panel)
 
+			// the done func of the modal runs in the event loop, and QueueUpdateDraw waits
+			// until the event loop runs the update, so it would never return here
 			if buttonLabel == doneLabel {
-				gui.App.QueueUpdateDraw(func() {
-					if err := doneFunc(); err != nil {
-						log.Println(err)
-						gui.Message(err.Error(), panel)
-					} else {
-						gui.FocusPanel(panel)
-					}
-				})
+				if err := doneFunc(); err != nil {
+					log.Println(err)
+					gui.Message(err.Error(), panel)
+				}
 			}
-			gui.FocusPanel(panel)
 		})
-	gui.Pages.AddAndSwitchToPage("confirm", gui.Modal(modal, 50, 29), true).ShowPage("main")
+	gui.Pages.AddAndSwitchToPage("confirm", gui.Modal(modal, 50, 29), true).ShowPage(panelPage(panel))
 }
 
 func (gui *Gui) Modal(p tview.Primitive, width, height int) tview.Primitive {
@@ -171,6 +252,14 @@ func (gui *Gui) FocusPanel(panel Panel) {
 		p = gui.FileBrowser
 	case BookmarkPanel:
 		p = gui.Bookmark
+	case PagerPanel:
+		p = gui.Pager
+	case UsagePanel:
+		p = gui.Usage
+	case DuplicatePanel:
+		p = gui.Duplicates
+	case ComparePanel:
+		p = gui.Compare
 	}
 
 	gui.CurrentPanel = panel
@@ -230,22 +319,12 @@ func (gui *Gui) Run() error {
 
 	gui.FileBrowser.ChangeDir(gui, currentDir, currentDir)
 
-	grid := tview.NewGrid().SetRows(1, 0).
-		AddItem(gui.InputPath, 0, 0, 1, 2, 0, 0, true)
-
-	if gui.Config.Preview.Enable {
-		grid.SetColumns(0, 0).
-			AddItem(gui.FileBrowser, 1, 0, 1, 1, 0, 0, true).
-			AddItem(gui.Preview, 1, 1, 1, 1, 0, 0, true)
-
-		gui.Preview.UpdateView(gui, gui.FileBrowser.GetSelectEntry())
-	} else {
-		grid.AddItem(gui.FileBrowser, 1, 0, 1, 2, 0, 0, true)
-	}
+	gui.grid = tview.NewGrid()
+	gui.layout()
 
 	gui.CurrentPanel = FileTablePanel
 	gui.SetKeybindings()
-	gui.Pages.AddAndSwitchToPage("main", grid, true)
+	gui.Pages.AddAndSwitchToPage("main", gui.grid, true)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	gui.ctxCancel = cancel
@@ -262,7 +341,13 @@ func (gui *Gui) Run() error {
 			select {
 			case <-t.C:
 				gui.App.QueueUpdateDraw(func() {
+					// don't poll s3 on every tick
+					if s3.IsPath(gui.InputPath.GetText()) {
+						return
+					}
 					gui.FileBrowser.UpdateView()
+					gui.RefreshGitStatus()
+					gui.RefreshDirSizes()
 				})
 			case <-ctx.Done():
 				return
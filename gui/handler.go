@@ -0,0 +1,132 @@
+package gui
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+)
+
+// shellQuote quote string for sh
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// detectMIME detect MIME type by contents, or by extension if contents is not available
+func detectMIME(entry *File) string {
+	b, err := readFileAt(entry, 0, 512)
+	if err != nil || len(b) == 0 {
+		mimeType := mime.TypeByExtension(filepath.Ext(entry.Name))
+		if mimeType == "" {
+			return "application/octet-stream"
+		}
+		return strings.Split(mimeType, ";")[0]
+	}
+
+	mimeType := strings.Split(http.DetectContentType(b), ";")[0]
+	// contents is too generic, extension may tell more
+	if mimeType == "text/plain" || mimeType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(entry.Name)); byExt != "" {
+			return strings.Split(byExt, ";")[0]
+		}
+	}
+	return mimeType
+}
+
+// match return true if the file matches extension (.pdf), glob (*.tar.gz) or MIME type (video/*)
+func (h PreviewHandler) match(name, mimeType string) bool {
+	for _, m := range h.Match {
+		switch {
+		case strings.Contains(m, "/"):
+			if ok, _ := path.Match(m, mimeType); ok {
+				return true
+			}
+		case strings.HasPrefix(m, "."):
+			if strings.EqualFold(filepath.Ext(name), m) {
+				return true
+			}
+		default:
+			if ok, _ := filepath.Match(m, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runHandler preview the file with the external command which matches the file.
+// return false if no handler matches or the command fails.
+func (p *Preview) runHandler(ctx context.Context, entry *File) (string, bool) {
+	// external commands can't read s3 objects
+	if len(p.handlers) == 0 || s3.IsPath(entry.PathName) {
+		return "", false
+	}
+
+	mimeType := detectMIME(entry)
+	for _, h := range p.handlers {
+		if !h.match(entry.Name, mimeType) {
+			continue
+		}
+
+		command := h.Command
+		if strings.Contains(command, "{}") {
+			command = strings.Replace(command, "{}", shellQuote(entry.PathName), -1)
+		} else {
+			command += " " + shellQuote(entry.PathName)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, p.handlerTimeout)
+		defer cancel()
+
+		cmd := exec.Command("sh", "-c", command)
+		// kill the whole process group, sh may not kill its children
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Println(err)
+			return "", false
+		}
+		if err := cmd.Start(); err != nil {
+			log.Printf("%s: %s\n", command, err)
+			return "", false
+		}
+
+		// kill only on timeout or truncation, the pgid may be reused after the process is reaped
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			case <-done:
+			}
+		}()
+
+		// the output over the limit is truncated
+		out, _ := ioutil.ReadAll(io.LimitReader(stdout, p.handlerMaxSize))
+		truncated := int64(len(out)) == p.handlerMaxSize
+		if truncated {
+			cancel()
+		}
+
+		err = cmd.Wait()
+		close(done)
+		if err != nil && !truncated {
+			log.Printf("%s: %s\n", command, err)
+			return "", false
+		}
+
+		return tview.TranslateANSI(string(out)), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,47 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunHandler(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	files := writeTestFiles(t, dir, "a.txt")
+
+	tests := []struct {
+		name    string
+		command string
+		maxSize int64
+		want    string
+		ok      bool
+	}{
+		{"path is appended", "cat", 100, "a.txt", true},
+		{"path is replaced", "printf %s {}", 100, filepath.Join(dir, "a.txt"), true},
+		{"failure", "exit 1", 100, "", false},
+		{"truncated", "yes; : {}", 5, "y\ny\ny", true},
+		{"timeout", "sleep 10; cat {}", 100, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Preview{
+				handlers:       []PreviewHandler{{Match: []string{".txt"}, Command: tt.command}},
+				handlerTimeout: 500 * time.Millisecond,
+				handlerMaxSize: tt.maxSize,
+			}
+			start := time.Now()
+			got, ok := p.runHandler(context.Background(), files[0])
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("runHandler() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+			if time.Since(start) > 2*time.Second {
+				t.Error("the command should be killed")
+			}
+		})
+	}
+}
@@ -20,17 +20,44 @@ var (
 		{"y": "copy selected file or directory"},
 		{"p": "paste file or directory"},
 		{"d": "delete selected file or directory"},
-		{"m": "make a new directory"},
+		{"N": "make a new directory"},
 		{"n": "make a new file"},
 		{"r": "rename a directory or file"},
 		{"e": "edit file with $EDITOR"},
+		{"v": "view file in full screen pager"},
+		{"space": "mark or unmark file"},
+		{"esc": "clear marks"},
+		{"D": "diff two marked files, or marked and selected file"},
+		{"ctrl-g": "open git menu for marked or selected files"},
+		{"i": "show file info"},
+		{"I": "toggle hiding ignored files"},
+		{"U": "analyze disk usage of the current directory"},
+		{"F": "find duplicate files in the current directory"},
+		{"=": "compare the current directory with another directory"},
+		{"c": "change permission of marked or selected files"},
+		{"C": "change owner and group of marked or selected files"},
+		{"R": "rename marked files or all files with $EDITOR"},
+		{"ctrl-r": "rename marked files or all files with pattern"},
+		{"s": "create symbolic link to marked or selected files"},
+		{"S": "create hard link to marked or selected files"},
+		{"z": "toggle hidden files"},
+		{"P": "toggle preview panel"},
+		{"T": "toggle table and tree"},
 		{"o": "open file or dierectory"},
 		{"f or /": "search files or directories"},
+		{"u": "compute sizes of directories"},
+		{"ctrl-s": "toggle sorting by size"},
 		{"ctrl-j": "scroll preview panel down"},
 		{"ctrl-k": "scroll preview panel up"},
+		{"ctrl-x": "toggle hex dump preview"},
+		{"ctrl-t": "toggle rendered and source markdown preview"},
 		{".": "edit config.yaml"},
 		{"b": "bookmark directory"},
 		{"B": "open bookmarks panel"},
+		{"` + key": "go to bookmark with the shortcut key"},
+		{"m + letter": "mark directory and selected entry"},
+		{"' + letter": "jump to the mark"},
+		{"''": "list marks"},
 	}
 
 	fileTreeHelps = []map[string]string{
@@ -46,28 +73,115 @@ var (
 		{"y": "copy selected file or directory"},
 		{"p": "paste file or directory"},
 		{"d": "delete selected file or directory"},
-		{"m": "make a new directory"},
+		{"N": "make a new directory"},
 		{"n": "make a new file"},
 		{"r": "rename a directory or file"},
 		{"e": "edit file with $EDITOR"},
+		{"v": "view file in full screen pager"},
+		{"space": "mark or unmark file"},
+		{"esc": "clear marks"},
+		{"D": "diff two marked files, or marked and selected file"},
+		{"ctrl-g": "open git menu for marked or selected files"},
+		{"i": "show file info"},
+		{"I": "toggle hiding ignored files"},
+		{"U": "analyze disk usage of the current directory"},
+		{"F": "find duplicate files in the current directory"},
+		{"=": "compare the current directory with another directory"},
+		{"c": "change permission of marked or selected files"},
+		{"C": "change owner and group of marked or selected files"},
+		{"R": "rename marked files or all files with $EDITOR"},
+		{"ctrl-r": "rename marked files or all files with pattern"},
+		{"s": "create symbolic link to marked or selected files"},
+		{"S": "create hard link to marked or selected files"},
+		{"z": "toggle hidden files"},
+		{"P": "toggle preview panel"},
+		{"T": "toggle table and tree"},
 		{"o": "open file or dierectory"},
 		{"f or /": "search files or directories"},
 		{"ctrl-j": "scroll preview panel down"},
 		{"ctrl-k": "scroll preview panel up"},
+		{"ctrl-x": "toggle hex dump preview"},
+		{"ctrl-t": "toggle rendered and source markdown preview"},
 		{".": "edit config.yaml"},
 		{"b": "bookmark directory"},
 		{"B": "open bookmarks panel"},
+		{"` + key": "go to bookmark with the shortcut key"},
+		{"m + letter": "mark directory and selected entry"},
+		{"' + letter": "jump to the mark"},
+		{"''": "list marks"},
 	}
 
 	pathHelps = []map[string]string{
 		{"enter": "change directory"},
 	}
 
+	pagerHelps = []map[string]string{
+		{"j": "scroll down"},
+		{"k": "scroll up"},
+		{"h": "scroll left"},
+		{"l": "scroll right"},
+		{"g": "move to top"},
+		{"G": "move to bottom"},
+		{"ctrl-f": "move next page"},
+		{"ctrl-b": "move previous page"},
+		{"ctrl-d": "move next half page"},
+		{"ctrl-u": "move previous half page"},
+		{"/": "search word"},
+		{"n": "move to next match"},
+		{"N": "move to previous match"},
+		{":": "go to line"},
+		{"#": "toggle line numbers"},
+		{"w": "toggle wrap"},
+		{"F": "toggle follow mode like tail -f"},
+		{"s": "toggle unified and side by side diff"},
+		{"]": "move to next hunk of diff"},
+		{"[": "move to previous hunk of diff"},
+		{"q or esc": "close pager"},
+	}
+
+	usageHelps = []map[string]string{
+		{"j": "move next"},
+		{"k": "move previous"},
+		{"l or enter": "show usage of the directory"},
+		{"h or backspace": "show usage of the parent directory"},
+		{"d": "delete selected file or directory"},
+		{"q or esc": "close usage"},
+	}
+
+	duplicateHelps = []map[string]string{
+		{"j": "move next"},
+		{"k": "move previous"},
+		{"space": "mark or unmark file"},
+		{"o": "mark all but the oldest copy"},
+		{"s": "mark all but the copy with the shortest path"},
+		{"c": "clear marks"},
+		{"d": "delete marked files"},
+		{"L": "replace marked files with hard links"},
+		{"q or esc": "close duplicates"},
+	}
+
+	compareHelps = []map[string]string{
+		{"j": "move next"},
+		{"k": "move previous"},
+		{"l": "expand directory"},
+		{"h": "collapse directory"},
+		{"s": "toggle hiding identical entries"},
+		{">": "copy missing or newer entries to the right"},
+		{"<": "copy missing or newer entries to the left"},
+		{"b": "copy missing or newer entries both ways"},
+		{"r": "compare again"},
+		{"q or esc": "close compare"},
+	}
+
 	bookmarkHelps = []map[string]string{
 		{"a": "add bookmark"},
+		{"e": "edit alias, tags and shortcut of bookmark"},
 		{"d": "delete bookmark"},
+		{"D": "delete bookmarks whose directories are missing"},
+		{"s": "toggle sorting by usage and by name"},
+		{"t": "filter bookmarks by tag"},
 		{"q": "close bookmarks panel"},
-		{"ctrl-g": "go to bookmark"},
+		{"ctrl-g or enter": "go to bookmark"},
 		{"f or /": "search bookmarks"},
 	}
 )
@@ -111,6 +225,14 @@ func (h *Help) UpdateView(panel Panel) {
 		keybindings = fileTreeHelps
 	case BookmarkPanel:
 		keybindings = bookmarkHelps
+	case PagerPanel:
+		keybindings = pagerHelps
+	case UsagePanel:
+		keybindings = usageHelps
+	case DuplicatePanel:
+		keybindings = duplicateHelps
+	case ComparePanel:
+		keybindings = compareHelps
 	}
 
 	for i, keybind := range keybindings {
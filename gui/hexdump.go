@@ -0,0 +1,101 @@
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+)
+
+// bytes to read for detecting binary
+const sniffLen = 8000
+
+// hexDumpWidth return bytes per line of hex dump which fits in the panel width
+func hexDumpWidth(width int) int {
+	// offset, hex and ascii columns take 14 + 4 cells per byte
+	n := 16
+	for n > 4 && 14+4*n > width {
+		n /= 2
+	}
+	return n
+}
+
+// isBinary detect binary content by NUL bytes or ratio of invalid UTF-8
+func isBinary(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	if bytes.IndexByte(b, 0) != -1 {
+		return true
+	}
+
+	var invalid, total int
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		// the last rune may be cut off by the sniff length
+		if r == utf8.RuneError && size == 1 && len(b) >= utf8.UTFMax {
+			invalid++
+		}
+		total++
+		b = b[size:]
+	}
+
+	return invalid*10 > total
+}
+
+// hexDump format b as offset, hex and ascii columns like `hexdump -C`
+func hexDump(b []byte, offset int64, width int) string {
+	var buf strings.Builder
+	for i := 0; i < len(b); i += width {
+		end := i + width
+		if end > len(b) {
+			end = len(b)
+		}
+		line := b[i:end]
+
+		fmt.Fprintf(&buf, "[yellow]%08x[-]  ", offset+int64(i))
+		for j := 0; j < width; j++ {
+			if j < len(line) {
+				fmt.Fprintf(&buf, "%02x ", line[j])
+			} else {
+				buf.WriteString("   ")
+			}
+			if j == width/2-1 {
+				buf.WriteString(" ")
+			}
+		}
+
+		ascii := make([]byte, len(line))
+		for j, c := range line {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			ascii[j] = c
+		}
+		fmt.Fprintf(&buf, " |%s|\n", tview.Escape(string(ascii)))
+	}
+
+	return buf.String()
+}
+
+// HexDump dump the head of the file as many lines as the panel can display,
+// and the rest is loaded when scrolling
+func (p *Preview) HexDump(entry *File, width, height int) (string, *stream) {
+	if height <= 0 {
+		height = 1
+	}
+	n := hexDumpWidth(width)
+
+	s := &stream{
+		entry: entry,
+		chunk: int64(height * n),
+		format: func(b []byte, offset int64) string {
+			return hexDump(b, offset, n)
+		},
+	}
+	text := p.readChunk(s)
+	s.chunk = int64(p.headLines * n)
+	return text, s
+}
@@ -0,0 +1,103 @@
+package gui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHexDumpWidth(t *testing.T) {
+	tests := []struct {
+		width int
+		want  int
+	}{
+		{100, 16},
+		{78, 16},
+		{77, 8},
+		{46, 8},
+		{45, 4},
+		{0, 4},
+	}
+	for _, tt := range tests {
+		if got := hexDumpWidth(tt.width); got != tt.want {
+			t.Errorf("hexDumpWidth(%d) = %d, want %d", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      string
+		offset int64
+		width  int
+		want   string
+	}{
+		{
+			name:   "full line",
+			b:      "ABCDEFGHIJKLMNOP",
+			offset: 0,
+			width:  16,
+			want:   "[yellow]00000000[-]  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50  |ABCDEFGHIJKLMNOP|\n",
+		},
+		{
+			name:   "short last line",
+			b:      "ABCDEFGHIJKLMNOPQ",
+			offset: 0x1000,
+			width:  16,
+			want: "[yellow]00001000[-]  41 42 43 44 45 46 47 48  49 4a 4b 4c 4d 4e 4f 50  |ABCDEFGHIJKLMNOP|\n" +
+				"[yellow]00001010[-]  51 " + strings.Repeat("   ", 7) + " " + strings.Repeat("   ", 8) + " |Q|\n",
+		},
+		{
+			name:   "non-printable",
+			b:      "\x00\t\x7f\xff",
+			offset: 0,
+			width:  4,
+			want:   "[yellow]00000000[-]  00 09  7f ff  |....|\n",
+		},
+		{
+			name:   "escape tags",
+			b:      "[a]",
+			offset: 0,
+			width:  4,
+			want:   "[yellow]00000000[-]  5b 61  5d     |[a[]|\n",
+		},
+		{
+			name:  "empty",
+			b:     "",
+			width: 16,
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hexDump([]byte(tt.b), tt.offset, tt.width); got != tt.want {
+				t.Errorf("hexDump() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("hello\nworld\n"), false},
+		{"utf-8", []byte("日本語のテキスト"), false},
+		{"NUL", []byte("text\x00text"), true},
+		{"invalid utf-8", bytes.Repeat([]byte{0xff, 'a'}, 10), true},
+		{"few invalid bytes", append(bytes.Repeat([]byte("a"), 100), 0xff, 'a', 'a', 'a'), false},
+		// the rune cut off by the sniff length is not invalid
+		{"cut off rune", []byte("ab日本語"[:10]), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinary(tt.b); got != tt.want {
+				t.Errorf("isBinary(%q) = %v, want %v", tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package gui
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+
+	"github.com/skanehira/ff/git"
+	"github.com/skanehira/ff/s3"
+)
+
+// ignoreFilter hide entries which are ignored by git or matched by the patterns of the config.
+// it isn't changed after the start, whether to hide them is passed by callers.
+type ignoreFilter struct {
+	gitignore bool
+	patterns  []string
+}
+
+var ignores = &ignoreFilter{}
+
+func newIgnoreFilter(config IgnoreConfig) *ignoreFilter {
+	return &ignoreFilter{
+		gitignore: config.Gitignore,
+		patterns:  config.Patterns,
+	}
+}
+
+func (f *ignoreFilter) matchPattern(name string) bool {
+	for _, pattern := range f.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPatterns split names into ignored by the patterns and the rest
+func (f *ignoreFilter) matchPatterns(names []string) (map[string]bool, []string) {
+	ignored := make(map[string]bool)
+	var rest []string
+	for _, name := range names {
+		if f.matchPattern(name) {
+			ignored[name] = true
+		} else {
+			rest = append(rest, name)
+		}
+	}
+	return ignored, rest
+}
+
+// Ignored get names of entries in the dir which should be hidden
+func (f *ignoreFilter) Ignored(ctx context.Context, dir string, names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+
+	ignored, rest := f.matchPatterns(names)
+	if !f.gitignore || len(rest) == 0 || s3.IsPath(dir) {
+		return ignored
+	}
+
+	matched, err := git.CheckIgnore(ctx, dir, rest)
+	if err != nil && err != git.ErrNotRepo && err != git.ErrNotInstalled {
+		log.Println(err)
+	}
+	for _, name := range matched {
+		ignored[name] = true
+	}
+	return ignored
+}
+
+// ignoreWalker check directories under the root with one git process while walking the tree
+type ignoreWalker struct {
+	filter  *ignoreFilter
+	root    string
+	checker *git.IgnoreChecker
+}
+
+// Walker start checking the directories under the root, Close must be called after the walk
+func (f *ignoreFilter) Walker(ctx context.Context, root string) *ignoreWalker {
+	w := &ignoreWalker{filter: f, root: root}
+	if !f.gitignore || s3.IsPath(root) {
+		return w
+	}
+
+	checker, err := git.NewIgnoreChecker(ctx, root)
+	if err != nil {
+		if err != git.ErrNotInstalled {
+			log.Println(err)
+		}
+		return w
+	}
+	w.checker = checker
+	return w
+}
+
+// Ignored get names of entries in the dir under the root which should be hidden
+func (w *ignoreWalker) Ignored(dir string, names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+
+	ignored, rest := w.filter.matchPatterns(names)
+	if w.checker == nil || len(rest) == 0 {
+		return ignored
+	}
+
+	rel, err := filepath.Rel(w.root, dir)
+	if err != nil {
+		log.Println(err)
+		return ignored
+	}
+	paths := make([]string, len(rest))
+	for i, name := range rest {
+		paths[i] = filepath.Join(rel, name)
+	}
+
+	matched, err := w.checker.Ignored(paths)
+	if err != nil {
+		if err != git.ErrNotRepo {
+			log.Println(err)
+		}
+		// don't start another process for each directory
+		w.checker = nil
+		return ignored
+	}
+	for _, p := range matched {
+		ignored[filepath.Base(p)] = true
+	}
+	return ignored
+}
+
+func (w *ignoreWalker) Close() {
+	if w.checker != nil {
+		w.checker.Close()
+	}
+}
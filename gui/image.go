@@ -0,0 +1,180 @@
+package gui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"path/filepath"
+	"strings"
+
+	// register image decoders
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+var imageExts = map[string]struct{}{
+	".png":  {},
+	".jpg":  {},
+	".jpeg": {},
+	".gif":  {},
+	".webp": {},
+}
+
+func isImage(name string) bool {
+	_, ok := imageExts[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// colorDepth describe color model of the image
+func colorDepth(model color.Model) string {
+	switch model {
+	case color.GrayModel:
+		return "8-bit gray"
+	case color.Gray16Model:
+		return "16-bit gray"
+	case color.RGBAModel, color.NRGBAModel:
+		return "32-bit RGBA"
+	case color.RGBA64Model, color.NRGBA64Model:
+		return "64-bit RGBA"
+	case color.YCbCrModel:
+		return "24-bit YCbCr"
+	case color.NYCbCrAModel:
+		return "32-bit YCbCrA"
+	case color.CMYKModel:
+		return "32-bit CMYK"
+	}
+
+	if p, ok := model.(color.Palette); ok {
+		return fmt.Sprintf("paletted %d colors", len(p))
+	}
+	return "unknown"
+}
+
+// averageColor average the colors in the rect and return color and whether it is opaque
+func averageColor(img image.Image, rect image.Rectangle) (r, g, b uint32, opaque bool) {
+	var sr, sg, sb, sa, n uint32
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			sr += pr >> 8
+			sg += pg >> 8
+			sb += pb >> 8
+			sa += pa >> 8
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0, 0, 0, false
+	}
+	return sr / n, sg / n, sb / n, sa/n >= 128
+}
+
+// Image render image as true color half-block characters.
+// one cell displays two vertical pixels, so the image is scaled to width x height*2 pixels.
+func (p *Preview) Image(ctx context.Context, entry *File, width, height int) string {
+	b, err := readFile(entry)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+
+	header := fmt.Sprintf("[yellow]%dx%d %s %s[-]\n", config.Width, config.Height,
+		strings.ToUpper(format), colorDepth(config.ColorModel))
+
+	// header takes one line
+	height--
+	if width <= 0 || height <= 0 || config.Width == 0 || config.Height == 0 {
+		return header
+	}
+
+	// keep aspect ratio, don't scale up
+	scale := float64(width) / float64(config.Width)
+	if s := float64(height*2) / float64(config.Height); s < scale {
+		scale = s
+	}
+	if scale > 1 {
+		scale = 1
+	}
+
+	cols := int(float64(config.Width) * scale)
+	rows := int(float64(config.Height) * scale)
+	if cols == 0 || rows == 0 {
+		return header
+	}
+
+	bounds := img.Bounds()
+	pixel := func(x, y int) image.Rectangle {
+		r := image.Rect(
+			bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale),
+			bounds.Min.X+int(float64(x+1)/scale), bounds.Min.Y+int(float64(y+1)/scale),
+		)
+		if r.Dx() == 0 {
+			r.Max.X++
+		}
+		if r.Dy() == 0 {
+			r.Max.Y++
+		}
+		return r.Intersect(bounds)
+	}
+
+	var buf strings.Builder
+	buf.WriteString(header)
+	for y := 0; y < rows; y += 2 {
+		if ctx.Err() != nil {
+			return buf.String()
+		}
+		lastTag := ""
+		for x := 0; x < cols; x++ {
+			tr, tg, tb, topOpaque := averageColor(img, pixel(x, y))
+			var br, bg, bb uint32
+			bottomOpaque := false
+			if y+1 < rows {
+				br, bg, bb, bottomOpaque = averageColor(img, pixel(x, y+1))
+			}
+
+			var tag, char string
+			switch {
+			case topOpaque && bottomOpaque:
+				tag = fmt.Sprintf("[#%02x%02x%02x:#%02x%02x%02x]", tr, tg, tb, br, bg, bb)
+				char = "▀"
+			case topOpaque:
+				tag = fmt.Sprintf("[#%02x%02x%02x:-]", tr, tg, tb)
+				char = "▀"
+			case bottomOpaque:
+				tag = fmt.Sprintf("[#%02x%02x%02x:-]", br, bg, bb)
+				char = "▄"
+			default:
+				tag = "[-:-]"
+				char = " "
+			}
+
+			if tag != lastTag {
+				buf.WriteString(tag)
+				lastTag = tag
+			}
+			buf.WriteString(char)
+		}
+		buf.WriteString("[-:-]\n")
+	}
+
+	return buf.String()
+}
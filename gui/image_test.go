@@ -0,0 +1,70 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestColorDepth(t *testing.T) {
+	tests := []struct {
+		model color.Model
+		want  string
+	}{
+		{color.GrayModel, "8-bit gray"},
+		{color.Gray16Model, "16-bit gray"},
+		{color.RGBAModel, "32-bit RGBA"},
+		{color.NRGBAModel, "32-bit RGBA"},
+		{color.RGBA64Model, "64-bit RGBA"},
+		{color.NRGBA64Model, "64-bit RGBA"},
+		{color.YCbCrModel, "24-bit YCbCr"},
+		{color.NYCbCrAModel, "32-bit YCbCrA"},
+		{color.CMYKModel, "32-bit CMYK"},
+		{color.Palette{color.Black, color.White}, "paletted 2 colors"},
+		{color.AlphaModel, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := colorDepth(tt.model); got != tt.want {
+			t.Errorf("colorDepth(%T) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestAverageColor(t *testing.T) {
+	// left half is red, right half is blue, the bottom right pixel is transparent
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			c := color.NRGBA{255, 0, 0, 255}
+			if x >= 2 {
+				c = color.NRGBA{0, 0, 255, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	img.Set(3, 1, color.NRGBA{})
+
+	tests := []struct {
+		name    string
+		rect    image.Rectangle
+		r, g, b uint32
+		opaque  bool
+	}{
+		{"one pixel", image.Rect(0, 0, 1, 1), 255, 0, 0, true},
+		{"red", image.Rect(0, 0, 2, 2), 255, 0, 0, true},
+		{"red and blue", image.Rect(1, 0, 3, 1), 127, 0, 127, true},
+		// transparent pixels are black after premultiplying alpha
+		{"half transparent", image.Rect(3, 0, 4, 2), 0, 0, 127, false},
+		{"mostly opaque", image.Rect(2, 0, 4, 2), 0, 0, 191, true},
+		{"empty", image.Rect(0, 0, 0, 0), 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, opaque := averageColor(img, tt.rect)
+			if r != tt.r || g != tt.g || b != tt.b || opaque != tt.opaque {
+				t.Errorf("averageColor() = %d, %d, %d, %v, want %d, %d, %d, %v",
+					r, g, b, opaque, tt.r, tt.g, tt.b, tt.opaque)
+			}
+		})
+	}
+}
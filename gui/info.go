@@ -0,0 +1,343 @@
+package gui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+	"golang.org/x/sys/unix"
+	"gopkg.in/djherbis/times.v1"
+)
+
+const (
+	// bytes to detect the text encoding
+	infoSampleSize = 64 * 1024
+	// long attribute values are cut off
+	maxXattrValue = 64
+)
+
+type infoRow struct {
+	key   string
+	value string
+	// load get the value which is slow to get in background, value is shown until it is done
+	load func(ctx context.Context) string
+}
+
+// loadingRow row whose value is got in background
+func loadingRow(key string, load func(ctx context.Context) string) infoRow {
+	return infoRow{key: key, value: "[gray]loading...[-]", load: load}
+}
+
+func mimeRow(entry *File) infoRow {
+	return loadingRow("MIME", func(ctx context.Context) string {
+		return detectMIME(entry)
+	})
+}
+
+func fileType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symbolic link"
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "block device"
+	}
+	return "regular file"
+}
+
+// detectEncoding detect the text encoding from the head of the file
+func detectEncoding(b []byte) string {
+	switch {
+	case len(b) == 0:
+		return "empty"
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8 with BOM"
+	case bytes.HasPrefix(b, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(b, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	case isBinary(b):
+		return "binary"
+	}
+
+	ascii := true
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return "us-ascii"
+	}
+	// the last rune may be cut off by the sample size
+	full := len(b) == infoSampleSize
+	for i := 0; i < utf8.UTFMax && len(b) > 0; i++ {
+		if utf8.Valid(b) {
+			return "utf-8"
+		}
+		if !full {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return "unknown 8-bit"
+}
+
+// countLines count lines of the file without reading it at once, it stops when ctx is done
+func countLines(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var count int
+	var last byte
+	r := bufio.NewReader(f)
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		n, err := r.Read(buf)
+		if n > 0 {
+			count += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// the last line doesn't end with newline
+	if last != 0 && last != '\n' {
+		count++
+	}
+	return count, nil
+}
+
+// xattrs list extended attributes as name=value, attributes of the symlink itself are listed
+func xattrs(path string) []string {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil
+	}
+
+	var attrs []string
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		var value string
+		if n, err := unix.Lgetxattr(path, name, nil); err == nil && n > 0 {
+			v := make([]byte, n)
+			if n, err = unix.Lgetxattr(path, name, v); err == nil {
+				v = v[:n]
+				if len(v) > maxXattrValue {
+					v = v[:maxXattrValue]
+				}
+				value = strconv.Quote(string(v))
+			}
+		}
+		attrs = append(attrs, name+"="+value)
+	}
+	return attrs
+}
+
+// fileInfo collect information of the entry, values are escaped.
+// values which are slow to get, like MIME of S3 objects, are loaded by Info in background
+func fileInfo(entry *File) ([]infoRow, error) {
+	if s3.IsPath(entry.PathName) {
+		rows := []infoRow{
+			{key: "Name", value: tview.Escape(entry.Name)},
+			{key: "Path", value: tview.Escape(entry.PathName)},
+			{key: "Size", value: fmt.Sprintf("%s (%d bytes)", humanize.Bytes(uint64(entry.Size)), entry.Size)},
+			{key: "Modify", value: entry.Change},
+		}
+		if !entry.IsDir {
+			rows = append(rows, mimeRow(entry))
+		}
+		return rows, nil
+	}
+
+	stat, err := os.Lstat(entry.PathName)
+	if err != nil {
+		return nil, err
+	}
+	mode := stat.Mode()
+
+	rows := []infoRow{
+		{key: "Name", value: tview.Escape(entry.Name)},
+		{key: "Path", value: tview.Escape(entry.PathName)},
+		{key: "Type", value: fileType(mode)},
+	}
+
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Readlink(entry.PathName)
+		if err != nil {
+			target = err.Error()
+		}
+		if _, err := os.Stat(entry.PathName); err != nil {
+			target = "[red]" + tview.Escape(target) + " (broken)[-]"
+		} else {
+			target = tview.Escape(target)
+		}
+		rows = append(rows, infoRow{key: "Target", value: target})
+	}
+
+	rows = append(rows,
+		infoRow{key: "Size", value: fmt.Sprintf("%s (%d bytes)", humanize.Bytes(uint64(stat.Size())), stat.Size())},
+		infoRow{key: "Permission", value: fmt.Sprintf("%s (%04o)", mode.String(), mode.Perm())},
+		infoRow{key: "Owner", value: tview.Escape(entry.Owner)},
+		infoRow{key: "Group", value: tview.Escape(entry.Group)},
+		infoRow{key: "Access", value: entry.Access},
+		infoRow{key: "Modify", value: stat.ModTime().Format(dateFmt)},
+	)
+	if t, err := times.Lstat(entry.PathName); err == nil && t.HasChangeTime() {
+		rows = append(rows, infoRow{key: "Change", value: t.ChangeTime().Format(dateFmt)})
+	}
+	if entry.Create != "" {
+		rows = append(rows, infoRow{key: "Birth", value: entry.Create})
+	}
+
+	if st, ok := stat.Sys().(*syscall.Stat_t); ok {
+		dev := uint64(st.Dev)
+		blocks := uint64(st.Blocks)
+		rows = append(rows,
+			infoRow{key: "Inode", value: strconv.FormatUint(uint64(st.Ino), 10)},
+			infoRow{key: "Device", value: fmt.Sprintf("%d,%d", unix.Major(dev), unix.Minor(dev))},
+			infoRow{key: "Links", value: strconv.FormatUint(uint64(st.Nlink), 10)},
+			// st_blocks is counted in 512 bytes
+			infoRow{key: "Blocks", value: fmt.Sprintf("%d (%s used, IO block %d)",
+				blocks, humanize.Bytes(blocks*512), int64(st.Blksize))},
+		)
+	}
+
+	if mode.IsRegular() {
+		rows = append(rows, mimeRow(entry))
+
+		b, err := readFileAt(entry, 0, infoSampleSize)
+		if err != nil {
+			log.Println(err)
+		}
+		encoding := detectEncoding(b)
+		rows = append(rows, infoRow{key: "Encoding", value: encoding})
+
+		if encoding != "binary" {
+			rows = append(rows, loadingRow("Lines", func(ctx context.Context) string {
+				lines, err := countLines(ctx, entry.PathName)
+				if err != nil {
+					if err != context.Canceled {
+						log.Println(err)
+					}
+					return "[red]" + tview.Escape(err.Error()) + "[-]"
+				}
+				return strconv.Itoa(lines)
+			}))
+		}
+	}
+
+	for i, attr := range xattrs(entry.PathName) {
+		key := ""
+		if i == 0 {
+			key = "Xattrs"
+		}
+		rows = append(rows, infoRow{key: key, value: tview.Escape(attr)})
+	}
+
+	return rows, nil
+}
+
+// Info show information of the entry
+func (gui *Gui) Info(entry *File) {
+	rows, err := fileInfo(entry)
+	if err != nil {
+		log.Println(err)
+		gui.Message(err.Error(), FileTablePanel)
+		return
+	}
+
+	pageName := "info"
+	ctx, cancel := context.WithCancel(context.Background())
+	closeInfo := func() {
+		cancel()
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+
+	table := tview.NewTable().SetSelectable(true, false)
+	table.SetBorder(true).SetTitle("info").SetTitleAlign(tview.AlignLeft)
+	for i, r := range rows {
+		table.SetCell(i, 0, &tview.TableCell{
+			Text:            r.key,
+			Align:           tview.AlignLeft,
+			Color:           tcell.ColorYellow,
+			BackgroundColor: tcell.ColorDefault,
+		})
+		table.SetCell(i, 1, tview.NewTableCell(r.value))
+	}
+
+	// slow values are loaded one by one not to read the file at the same time
+	go func() {
+		for i, r := range rows {
+			if r.load == nil {
+				continue
+			}
+			value := r.load(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			i := i
+			gui.App.QueueUpdateDraw(func() {
+				if ctx.Err() == nil {
+					table.GetCell(i, 1).SetText(value)
+				}
+			})
+		}
+	}()
+
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			closeInfo()
+		}
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q', 'i':
+			closeInfo()
+			return nil
+		}
+		return event
+	})
+
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(table, 0, len(rows)+2), true).ShowPage("main")
+}
@@ -0,0 +1,42 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountLines(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"empty", "", 0},
+		{"one line", "a\n", 1},
+		{"no newline", "a\nb", 2},
+		{"empty lines", "\n\n\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTestContents(t, dir, map[string]string{tt.name: tt.content})
+			got, err := countLines(context.Background(), filepath.Join(dir, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("countLines(%q) = %d, want %d", tt.content, got, tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := countLines(ctx, filepath.Join(dir, "one line")); err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/skanehira/ff/s3"
 	"github.com/skanehira/ff/system"
 )
 
@@ -22,6 +23,68 @@ var (
 	ErrNoNewName       = errors.New("no new name")
 )
 
+// prefixKeybinding handle the key which follows the prefix key,
+// return true if the key is consumed
+func (gui *Gui) prefixKeybinding(event *tcell.EventKey) bool {
+	prefix := gui.prefixKey
+	gui.prefixKey = 0
+
+	switch prefix {
+	case bookmarkPrefixKey:
+		if event.Key() != tcell.KeyRune {
+			return true
+		}
+		entry, err := gui.Bookmark.FindShortcut(event.Rune())
+		if err == nil {
+			err = gui.Bookmark.Go(gui, entry)
+		}
+		if err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+		return true
+	case setMarkKey:
+		if event.Key() != tcell.KeyRune {
+			return true
+		}
+		if err := gui.SetMark(event.Rune()); err != nil {
+			log.Println(err)
+			gui.Message(err.Error(), FileTablePanel)
+		}
+		return true
+	case jumpMarkKey:
+		if event.Key() != tcell.KeyRune {
+			return true
+		}
+		// '' or the letter which isn't marked shows the list
+		r := event.Rune()
+		if _, err := gui.DirMarks.Get(r); err != nil {
+			gui.MarkList()
+			return true
+		}
+		if err := gui.JumpMark(r); err != nil {
+			log.Println(err)
+			gui.Message(err.Error(), FileTablePanel)
+		}
+		return true
+	}
+
+	if event.Key() != tcell.KeyRune {
+		return false
+	}
+	switch event.Rune() {
+	case bookmarkPrefixKey:
+		if !gui.Config.Bookmark.Enable {
+			return false
+		}
+		gui.prefixKey = event.Rune()
+		return true
+	case setMarkKey, jumpMarkKey:
+		gui.prefixKey = event.Rune()
+		return true
+	}
+	return false
+}
+
 func (gui *Gui) commonFileBrowserKeybinding(event *tcell.EventKey) {
 	if gui.Config.Preview.Enable {
 		switch event.Key() {
@@ -29,12 +92,27 @@ func (gui *Gui) commonFileBrowserKeybinding(event *tcell.EventKey) {
 			gui.Preview.ScrollDown()
 		case tcell.KeyCtrlK:
 			gui.Preview.ScrollUp()
+		case tcell.KeyCtrlX:
+			gui.Preview.ToggleHexMode(gui, gui.FileBrowser.GetSelectEntry())
+		case tcell.KeyCtrlT:
+			gui.Preview.ToggleMarkdownSource(gui, gui.FileBrowser.GetSelectEntry())
 		}
 	}
 
 	switch event.Key() {
 	case tcell.KeyTab:
 		gui.App.SetFocus(gui.InputPath)
+	case tcell.KeyEscape:
+		marks.Clear()
+		gui.FileBrowser.UpdateView()
+	case tcell.KeyCtrlG:
+		if gui.Config.Git.Enable {
+			gui.GitMenu()
+		}
+	case tcell.KeyCtrlR:
+		if err := gui.PatternRename(); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
 	}
 
 	switch event.Rune() {
@@ -45,6 +123,11 @@ func (gui *Gui) commonFileBrowserKeybinding(event *tcell.EventKey) {
 			return
 		}
 
+		if s3.IsPath(entry.PathName) {
+			gui.Message(ErrNotSupported.Error(), FileTablePanel)
+			return
+		}
+
 		if err := gui.EditFile(entry.PathName); err != nil {
 			gui.Message(err.Error(), FileTablePanel)
 		}
@@ -52,15 +135,81 @@ func (gui *Gui) commonFileBrowserKeybinding(event *tcell.EventKey) {
 	case 'q':
 		gui.Stop()
 
+	case 'v':
+		entry := gui.FileBrowser.GetSelectEntry()
+		if entry == nil || entry.IsDir {
+			return
+		}
+		if err := gui.Pager.Open(gui, entry); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+
 	case 'o':
 		entry := gui.FileBrowser.GetSelectEntry()
 		if entry == nil {
 			return
 		}
+		if s3.IsPath(entry.PathName) {
+			gui.Message(ErrNotSupported.Error(), FileTablePanel)
+			return
+		}
 		if err := system.Open(entry.PathName); err != nil {
 			gui.Message(err.Error(), FileTablePanel)
 		}
 
+	case 'D':
+		if err := gui.DiffFiles(); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+
+	case 'i':
+		entry := gui.FileBrowser.GetSelectEntry()
+		if entry != nil {
+			gui.Info(entry)
+		}
+
+	case 'I':
+		gui.ToggleIgnored()
+
+	case 'U':
+		if err := gui.Usage.Scan(gui, gui.InputPath.GetText()); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+
+	case 'F':
+		if err := gui.Duplicates.Scan(gui, gui.InputPath.GetText()); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+
+	case '=':
+		gui.CompareForm()
+
+	case 'c':
+		gui.ChmodForm()
+
+	case 'C':
+		gui.ChownForm()
+
+	case 'R':
+		if err := gui.BulkRename(); err != nil {
+			gui.Message(err.Error(), FileTablePanel)
+		}
+
+	case 's':
+		gui.MakeLinks(false)
+
+	case 'S':
+		gui.MakeLinks(true)
+
+	case 'z':
+		gui.ToggleHidden()
+
+	case 'P':
+		gui.TogglePreview()
+
+	case 'T':
+		gui.ToggleTree()
+
 	case '.':
 		if err := gui.EditFile(gui.Config.ConfigFile); err != nil {
 			gui.Message(err.Error(), FileTablePanel)
@@ -93,6 +242,10 @@ func (gui *Gui) SetKeybindings() {
 	gui.FileBrowser.Keybinding(gui)
 	gui.InputPathKeybinding()
 	gui.Help.Keybinding(gui)
+	gui.Pager.Keybinding(gui)
+	gui.Usage.Keybinding(gui)
+	gui.Duplicates.Keybinding(gui)
+	gui.Compare.Keybinding(gui)
 
 	if gui.Config.Bookmark.Enable {
 		gui.Bookmark.BookmarkKeybinding(gui)
@@ -167,6 +320,15 @@ func (gui *Gui) InputPathKeybinding() {
 	gui.InputPath.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEnter {
 			path := os.ExpandEnv(gui.InputPath.GetText())
+			if s3.IsPath(path) {
+				if err := gui.FileBrowser.ChangeDir(gui, parentPath(path), path); err != nil {
+					gui.Message(err.Error(), FileTablePanel)
+					return
+				}
+				gui.FocusPanel(FileTablePanel)
+				return
+			}
+
 			file, err := os.Lstat(path)
 			if err != nil {
 				log.Println(err)
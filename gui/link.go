@@ -0,0 +1,96 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+var (
+	linkColor       = tcell.ColorAqua
+	brokenLinkColor = tcell.ColorRed
+)
+
+// followSymlinks treat symlinks to directories as directories
+var followSymlinks = true
+
+// displayName file name with the symlink target
+func displayName(f *File) string {
+	if f.IsLink {
+		return f.Name + " -> " + f.LinkTarget
+	}
+	return f.Name
+}
+
+// isSymlinkLoop return true if the directory is the parent of itself
+func isSymlinkLoop(dir string) bool {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(dir))
+	if err != nil {
+		return false
+	}
+	return parent == real || strings.HasPrefix(parent, real+string(filepath.Separator))
+}
+
+// makeLink create symbolic or hard link to the entry
+func makeLink(entry *File, link string, hard bool) error {
+	if s3.IsPath(entry.PathName) || s3.IsPath(link) {
+		return ErrNotSupported
+	}
+	if hard {
+		return system.Link(entry.PathName, link)
+	}
+	return system.Symlink(entry.PathName, link)
+}
+
+// MakeLinks create links to the marked files in the current directory,
+// or a link to the selected file with the input name
+func (gui *Gui) MakeLinks(hard bool) {
+	kind := "symbolic link"
+	if hard {
+		kind = "hard link"
+	}
+	current := gui.InputPath.GetText()
+
+	if files := marks.Files(); len(files) > 0 {
+		message := fmt.Sprintf("do you want to create %ss to %d marked files here?", kind, len(files))
+		gui.Confirm(message, "create", FileTablePanel, func() error {
+			for _, f := range files {
+				if err := makeLink(f, joinPath(current, f.Name), hard); err != nil {
+					return err
+				}
+			}
+			marks.Clear()
+			gui.FileBrowser.UpdateView()
+			return nil
+		})
+		return
+	}
+
+	entry := gui.FileBrowser.GetSelectEntry()
+	if entry == nil {
+		return
+	}
+
+	gui.Form(map[string]string{"name": ""}, "create", kind+" to "+entry.Name, "create_link", FileTablePanel,
+		7, func(values map[string]string) error {
+			name := values["name"]
+			if name == "" {
+				return ErrNoFileName
+			}
+
+			if err := makeLink(entry, joinPath(current, name), hard); err != nil {
+				return err
+			}
+
+			gui.FileBrowser.UpdateView()
+			return nil
+		})
+}
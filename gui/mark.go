@@ -0,0 +1,53 @@
+package gui
+
+import "github.com/gdamore/tcell/v2"
+
+// color of the marked files
+const markColor = tcell.ColorFuchsia
+
+// marks files which are marked across directories
+var marks = &markedFiles{}
+
+// markedFiles marked files in marked order
+type markedFiles struct {
+	files []*File
+}
+
+// Toggle mark the file, or unmark the file if it is marked
+func (m *markedFiles) Toggle(file *File) {
+	for i, f := range m.files {
+		if f.PathName == file.PathName {
+			m.files = append(m.files[:i], m.files[i+1:]...)
+			return
+		}
+	}
+	m.files = append(m.files, file)
+}
+
+func (m *markedFiles) Has(path string) bool {
+	for _, f := range m.files {
+		if f.PathName == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *markedFiles) Files() []*File {
+	return m.files
+}
+
+func (m *markedFiles) Clear() {
+	m.files = nil
+}
+
+// selectedFiles marked files, or the selected file if no files are marked
+func (gui *Gui) selectedFiles() []*File {
+	if files := marks.Files(); len(files) > 0 {
+		return files
+	}
+	if entry := gui.FileBrowser.GetSelectEntry(); entry != nil {
+		return []*File{entry}
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+)
+
+// ASCII punctuation which can be escaped with a backslash in CommonMark
+const mdEscapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+var (
+	mdHeading     = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdFence       = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([^`\\s]*)")
+	mdRule        = regexp.MustCompile(`^ {0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	mdSetext      = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	mdBullet      = regexp.MustCompile(`^(\s*)([-*+])\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^(\s*)(\d+)([.)])\s+(.*)$`)
+	mdTask        = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^ {0,3}>\s?(.*)$`)
+	mdTableDelim  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdIndentCode  = regexp.MustCompile(`^( {4}|\t)(.*)$`)
+	mdInlineLink  = regexp.MustCompile(`^!?\[([^\]]*)\]\(([^)]*)\)`)
+	mdRefLink     = regexp.MustCompile(`^!?\[([^\]]*)\]\[[^\]]*\]`)
+	mdLinkDef     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s+\S+`)
+	mdHeadingTags = []string{"[yellow::bu]", "[yellow::b]", "[aqua::b]", "[aqua::b]", "[aqua::b]", "[aqua::b]"}
+)
+
+func isMarkdown(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mkd", ".mdown":
+		return true
+	}
+	return false
+}
+
+// inline style of markdown
+type mdStyle struct {
+	strong, emphasis bool
+}
+
+func (s mdStyle) tag() string {
+	attrs := ""
+	if s.strong {
+		attrs += "b"
+	}
+	if s.emphasis {
+		attrs += "u"
+	}
+	if attrs == "" {
+		attrs = "-"
+	}
+	return "[::" + attrs + "]"
+}
+
+// renderInline render emphasis, code spans and links
+func renderInline(text string) string {
+	var buf, literal strings.Builder
+	var style mdStyle
+
+	flush := func() {
+		buf.WriteString(tview.Escape(literal.String()))
+		literal.Reset()
+	}
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	// wordBefore and wordAt decode the rune which ends before or starts at the byte i
+	wordBefore := func(i int) bool {
+		r, _ := utf8.DecodeLastRuneInString(text[:i])
+		return isWord(r)
+	}
+	wordAt := func(i int) bool {
+		if i >= len(text) {
+			return false
+		}
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		return isWord(r)
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		rest := text[i:]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(mdEscapable, text[i+1]) >= 0:
+			literal.WriteByte(text[i+1])
+			i++
+
+		case c == '`':
+			n := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:n]
+			end := strings.Index(rest[n:], fence)
+			if end == -1 {
+				literal.WriteString(fence)
+				i += n - 1
+				continue
+			}
+			flush()
+			code := strings.TrimSpace(rest[n : n+end])
+			buf.WriteString("[#ff8700]" + tview.Escape(code) + "[-]")
+			i += n + end + n - 1
+
+		case c == '[' || (c == '!' && strings.HasPrefix(rest, "![")):
+			m := mdInlineLink.FindStringSubmatch(rest)
+			if m == nil {
+				m = mdRefLink.FindStringSubmatch(rest)
+			}
+			if m == nil {
+				literal.WriteByte(c)
+				continue
+			}
+			flush()
+			label := m[1]
+			if c == '!' {
+				label = "image: " + label
+			}
+			link := style
+			link.emphasis = true
+			buf.WriteString("[blue]" + link.tag() + tview.Escape(label) + style.tag() + "[-]")
+			i += len(m[0]) - 1
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			// intraword underscores are not emphasis
+			if c == '_' && wordBefore(i) && wordAt(i+2) {
+				literal.WriteString(rest[:2])
+				i++
+				continue
+			}
+			flush()
+			style.strong = !style.strong
+			buf.WriteString(style.tag())
+			i++
+
+		case c == '*' || c == '_':
+			// intraword underscore is not emphasis
+			if c == '_' && wordBefore(i) && wordAt(i+1) {
+				literal.WriteByte(c)
+				continue
+			}
+			// single asterisk surrounded by spaces is not emphasis
+			if !style.emphasis && (i+1 >= len(text) || text[i+1] == ' ') {
+				literal.WriteByte(c)
+				continue
+			}
+			flush()
+			style.emphasis = !style.emphasis
+			buf.WriteString(style.tag())
+
+		default:
+			literal.WriteByte(c)
+		}
+	}
+
+	flush()
+	if style.strong || style.emphasis {
+		buf.WriteString("[::-]")
+	}
+	return buf.String()
+}
+
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderTable render table rows with aligned columns
+func renderTable(header, delim string, rows []string) string {
+	aligns := splitTableRow(delim)
+	table := [][]string{splitTableRow(header)}
+	for _, r := range rows {
+		table = append(table, splitTableRow(r))
+	}
+
+	var widths []int
+	for r, row := range table {
+		for c, cell := range row {
+			cell = renderInline(cell)
+			table[r][c] = cell
+			w := tview.TaggedStringWidth(cell)
+			if c >= len(widths) {
+				widths = append(widths, w)
+			} else if w > widths[c] {
+				widths[c] = w
+			}
+		}
+	}
+
+	var buf strings.Builder
+	for r, row := range table {
+		var cells []string
+		for c, w := range widths {
+			var cell string
+			if c < len(row) {
+				cell = row[c]
+			}
+			pad := w - tview.TaggedStringWidth(cell)
+			align := ""
+			if c < len(aligns) {
+				align = aligns[c]
+			}
+			switch {
+			case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+				cell = strings.Repeat(" ", pad/2) + cell + strings.Repeat(" ", pad-pad/2)
+			case strings.HasSuffix(align, ":"):
+				cell = strings.Repeat(" ", pad) + cell
+			default:
+				cell += strings.Repeat(" ", pad)
+			}
+			if r == 0 {
+				cell = "[::b]" + cell + "[::-]"
+			}
+			cells = append(cells, cell)
+		}
+		buf.WriteString(strings.Join(cells, " │ ") + "\n")
+
+		if r == 0 {
+			var lines []string
+			for _, w := range widths {
+				lines = append(lines, strings.Repeat("─", w))
+			}
+			buf.WriteString(strings.Join(lines, "─┼─") + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// renderMarkdown render markdown as tview tagged text, it stops when ctx is done.
+// code blocks are highlighted by highlight.
+func renderMarkdown(ctx context.Context, text string, width int, highlight func(lang, code string) string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+
+	var buf strings.Builder
+	var paragraph []string
+	inList := false
+
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		buf.WriteString(renderInline(strings.Join(paragraph, " ")) + "\n")
+		paragraph = nil
+	}
+
+	if width <= 0 {
+		width = 40
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if ctx.Err() != nil {
+			return buf.String()
+		}
+		line := lines[i]
+
+		// fenced code block
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			highlighted := strings.Split(highlight(m[2], strings.Join(code, "\n")+"\n"), "\n")
+			// the highlighted code may end with only tags
+			for len(highlighted) > len(code) && tview.TaggedStringWidth(highlighted[len(highlighted)-1]) == 0 {
+				highlighted = highlighted[:len(highlighted)-1]
+			}
+			for _, l := range highlighted {
+				buf.WriteString("  " + l + "\n")
+			}
+			inList = false
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			buf.WriteString("\n")
+			continue
+		}
+
+		// setext heading
+		if len(paragraph) > 0 && mdSetext.MatchString(line) {
+			level := 1
+			if strings.TrimSpace(line)[0] == '-' {
+				level = 2
+			}
+			heading := strings.Join(paragraph, " ")
+			paragraph = nil
+			buf.WriteString(mdHeadingTags[level-1] + renderInline(heading) + "[-::-]\n")
+			continue
+		}
+
+		if mdRule.MatchString(line) {
+			flushParagraph()
+			buf.WriteString("[gray]" + strings.Repeat("─", width) + "[-]\n")
+			inList = false
+			continue
+		}
+
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			level := len(m[1])
+			buf.WriteString(mdHeadingTags[level-1] + renderInline(m[2]) + "[-::-]\n")
+			inList = false
+			continue
+		}
+
+		if m := mdQuote.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			buf.WriteString("[gray]│[-] " + renderInline(m[1]) + "\n")
+			continue
+		}
+
+		// table needs header and delimiter rows
+		if strings.Contains(line, "|") && i+1 < len(lines) && mdTableDelim.MatchString(lines[i+1]) &&
+			strings.Contains(lines[i+1], "-") {
+			flushParagraph()
+			header, delim := line, lines[i+1]
+			var rows []string
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, lines[i])
+			}
+			i--
+			buf.WriteString(renderTable(header, delim, rows))
+			inList = false
+			continue
+		}
+
+		if m := mdBullet.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			item := m[3]
+			bullet := "•"
+			if t := mdTask.FindStringSubmatch(item); t != nil {
+				bullet = "☐"
+				if t[1] != " " {
+					bullet = "☑"
+				}
+				item = t[2]
+			}
+			buf.WriteString(m[1] + "  " + bullet + " " + renderInline(item) + "\n")
+			inList = true
+			continue
+		}
+
+		if m := mdOrdered.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			buf.WriteString(fmt.Sprintf("%s  %s%s %s\n", m[1], m[2], m[3], renderInline(m[4])))
+			inList = true
+			continue
+		}
+
+		// indented code block, but indented lines in list are continuation of the item
+		if m := mdIndentCode.FindStringSubmatch(line); m != nil && len(paragraph) == 0 && !inList {
+			buf.WriteString("  [#ff8700]" + tview.Escape(m[2]) + "[-]\n")
+			continue
+		}
+
+		if mdLinkDef.MatchString(line) {
+			continue
+		}
+
+		if inList && len(paragraph) == 0 && (line[0] == ' ' || line[0] == '\t') {
+			buf.WriteString("    " + renderInline(strings.TrimSpace(line)) + "\n")
+			continue
+		}
+		inList = false
+
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+
+	flushParagraph()
+	return buf.String()
+}
@@ -0,0 +1,67 @@
+package gui
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"**bold** text", "[::b]bold[::-] text"},
+		{"*em* and _em_", "[::u]em[::-] and [::u]em[::-]"},
+		{"**unclosed", "[::b]unclosed[::-]"},
+		{"a * b", "a * b"},
+		{"snake_case_name", "snake_case_name"},
+		// intraword underscores between multibyte letters
+		{"日本_語_です", "日本_語_です"},
+		{"é_é_é", "é_é_é"},
+		{"`a*b*`", "[#ff8700]a*b*[-]"},
+		{"``a ` b``", "[#ff8700]a ` b[-]"},
+		{"[link](http://example.com)", "[blue][::u]link[::-][-]"},
+		{"![img](a.png)", "[blue][::u]image: img[::-][-]"},
+		{`\*not\*`, "*not*"},
+		{"\\`not code\\`", "`not code`"},
+		{`a \| b \<c\> \~ \$`, "a | b <c> ~ $"},
+		{`\a \\`, `\a \`},
+		{"[tag]", "[tag[]"},
+	}
+	for _, tt := range tests {
+		if got := renderInline(tt.text); got != tt.want {
+			t.Errorf("renderInline(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	highlight := func(lang, code string) string {
+		return "<" + lang + ">" + code
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"heading", "# Title\n## Sub", "[yellow::bu]Title[-::-]\n[yellow::b]Sub[-::-]\n"},
+		{"setext heading", "Sub\n---", "[yellow::b]Sub[-::-]\n"},
+		{"paragraph", "some *text*\nwrapped", "some [::u]text[::-] wrapped\n"},
+		{"list", "- a\n- [x] done\n  more\n- [ ] todo\n1. one", "  • a\n  ☑ done\n    more\n  ☐ todo\n  1. one\n"},
+		{"fenced code", "```go\nx := 1\n```", "  <go>x := 1\n"},
+		{"indented code", "    code", "  [#ff8700]code[-]\n"},
+		{"quote", "> quote", "[gray]│[-] quote\n"},
+		{"rule", "---", "[gray]──────────[-]\n"},
+		{"table", "| a | b |\n|---|---|\n| 1 | 22 |", "[::b]a[::-] │ [::b]b [::-]\n──┼───\n1 │ 22\n"},
+		{"link definition", "[a]: http://example.com", ""},
+		{"crlf", "# Title\r\ntext", "[yellow::bu]Title[-::-]\ntext\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(context.Background(), tt.text, 10, highlight); got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+)
+
+// interval to check the file in follow mode
+const followInterval = time.Second
+
+// Pager full screen viewer of the file
+type Pager struct {
+	*tview.TextView
+	colorscheme string
+	maxSize     int64
+	ignorecase  bool
+	title       string
+	entry       *File
+	binary      bool
+	// bytes of the file from start to offset are in memory,
+	// large files are read in windows and the rest is read in follow mode
+	start  int64
+	offset int64
+	// offsets of the lines from start
+	starts []int64
+	// line number of the first line in memory, -1 if it is unknown
+	firstLine   int
+	index       *lineIndex
+	indexCancel context.CancelFunc
+	// rendered lines and lines without tags
+	lines []string
+	raw   []string
+	// the search out of the window
+	searchCancel context.CancelFunc
+	status       string
+	// line of each search match
+	matches     []int
+	current     int
+	searchWord  string
+	gotoLine    int
+	lineNumbers bool
+	wrap        bool
+	follow      bool
+	cancel      context.CancelFunc
+	diff        *fileDiff
+	sideBySide  bool
+	hunk        int
+}
+
+func NewPager(config PreviewConfig, ignorecase bool) *Pager {
+	p := &Pager{
+		TextView:    tview.NewTextView(),
+		colorscheme: config.Colorscheme,
+		maxSize:     config.MaxSize,
+		ignorecase:  ignorecase,
+		wrap:        true,
+		gotoLine:    -1,
+	}
+	if p.maxSize <= 0 {
+		p.maxSize = defaultMaxPreviewSize
+	}
+
+	p.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+	p.SetDynamicColors(true).SetRegions(true)
+	return p
+}
+
+// plainText strip color tags from text
+func plainText(text string) string {
+	return tview.NewTextView().SetDynamicColors(true).SetText(text).GetText(true)
+}
+
+// Open open the file in the pager, only a window of the large file is read
+func (p *Pager) Open(gui *Gui, entry *File) error {
+	head, err := readFileAt(entry, 0, sniffLen)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	p.Show(gui, entry.Name, "")
+	p.entry = entry
+	p.binary = isBinary(head)
+	p.startIndex(gui)
+	return p.load(0)
+}
+
+// format highlight the window which is read from the offset, or dump it if the file is binary
+func (p *Pager) format(b []byte, offset int64) string {
+	return p.formatText(p.entry.Name, b, offset, p.binary)
+}
+
+// formatBlob highlight the whole content, or dump it if it is binary
+func (p *Pager) formatBlob(name string, b []byte) string {
+	head := b
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	return p.formatText(name, b, 0, isBinary(head))
+}
+
+func (p *Pager) formatText(name string, b []byte, offset int64, binary bool) string {
+	switch {
+	case binary:
+		return hexDump(b, offset, 16)
+	case int64(len(b)) > p.maxSize:
+		// highlighting large file is slow
+		return tview.Escape(string(b))
+	}
+	return highlightCode(context.Background(), p.colorscheme, name, string(b))
+}
+
+// Show show the tagged text in the pager
+func (p *Pager) Show(gui *Gui, title, text string) {
+	p.stopFollow()
+	p.stopSearch()
+	if p.indexCancel != nil {
+		p.indexCancel()
+		p.indexCancel = nil
+	}
+	p.title = title
+	p.entry = nil
+	p.binary = false
+	p.index = nil
+	p.start, p.offset = 0, 0
+	p.starts = nil
+	p.firstLine = 0
+	p.diff = nil
+	p.setText(text)
+
+	gui.Pages.AddAndSwitchToPage("pager", p, true)
+	gui.FocusPanel(PagerPanel)
+}
+
+func (p *Pager) setText(text string) {
+	p.searchWord = ""
+	p.hunk = 0
+	p.setLines(text)
+	p.ScrollToBeginning()
+}
+
+// setLines set the lines in memory, the search word is kept to search in windows of the file
+func (p *Pager) setLines(text string) {
+	// the last empty line is kept to append the text in follow mode
+	p.lines = strings.Split(text, "\n")
+	p.raw = strings.Split(plainText(text), "\n")
+	p.matches = nil
+	p.gotoLine = -1
+	p.Highlight()
+
+	p.render()
+}
+
+// lineCount number of lines which are shown, the last empty line is not shown
+func (p *Pager) lineCount() int {
+	if n := len(p.lines); n > 0 && p.lines[n-1] == "" {
+		return n - 1
+	}
+	return len(p.lines)
+}
+
+// digits width of line numbers
+func (p *Pager) digits() int {
+	last := p.firstLine + len(p.lines)
+	if p.binary {
+		last = int(p.entry.Size/16) + 1
+	} else if p.index != nil {
+		if lines, ok := p.index.Lines(); ok && lines > last {
+			last = lines
+		}
+	}
+	return len(fmt.Sprint(last))
+}
+
+func (p *Pager) searchPattern() *regexp.Regexp {
+	expr := regexp.QuoteMeta(p.searchWord)
+	if p.ignorecase {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+// ShowDiff show the diff in the pager
+func (p *Pager) ShowDiff(gui *Gui, d *fileDiff) {
+	p.Show(gui, fmt.Sprintf("diff %s %s", d.aName, d.bName), "")
+	p.diff = d
+	p.renderDiff(gui)
+}
+
+func (p *Pager) renderDiff(gui *Gui) {
+	if p.sideBySide {
+		// pager is full screen
+		_, _, width, _ := gui.Pages.GetRect()
+		p.setText(p.diff.SideBySide(width - 2))
+	} else {
+		p.setText(p.diff.Unified())
+	}
+}
+
+// ToggleDiffLayout toggle unified and side by side diff
+func (p *Pager) ToggleDiffLayout(gui *Gui) {
+	if p.diff == nil {
+		return
+	}
+	p.sideBySide = !p.sideBySide
+	p.renderDiff(gui)
+}
+
+// NextHunk jump to the next hunk of the diff, direction is 1 or -1
+func (p *Pager) NextHunk(direction int) {
+	if p.diff == nil || len(p.diff.hunks) == 0 {
+		return
+	}
+
+	// the first jump shows the first hunk
+	if len(p.GetHighlights()) > 0 {
+		p.hunk = (p.hunk + direction + len(p.diff.hunks)) % len(p.diff.hunks)
+	}
+	p.Highlight(fmt.Sprintf("h%d", p.hunk)).ScrollToHighlight()
+	p.updateTitle()
+}
+
+// Close close the pager and stop following the file
+func (p *Pager) Close(gui *Gui) {
+	p.Show(gui, "", "")
+	gui.Pages.RemovePage("pager").SwitchToPage("main")
+	gui.FocusPanel(FileTablePanel)
+}
+
+// render set lines with line numbers, search matches and goto line
+func (p *Pager) render() {
+	var pattern *regexp.Regexp
+	if p.searchWord != "" {
+		pattern = p.searchPattern()
+	}
+
+	p.matches = nil
+	digits := p.digits()
+
+	var buf strings.Builder
+	for i, line := range p.lines {
+		if i == len(p.lines)-1 && line == "" {
+			break
+		}
+		if p.lineNumbers {
+			if p.firstLine >= 0 {
+				fmt.Fprintf(&buf, "[gray::-]%*d[-::-] ", digits, p.firstLine+i+1)
+			} else {
+				// the line number is unknown until lines are indexed
+				fmt.Fprintf(&buf, "[gray::-]%*s[-::-] ", digits, "?")
+			}
+		}
+
+		// matched line loses the syntax colors to show matches
+		if pattern != nil {
+			if locs := pattern.FindAllStringIndex(p.raw[i], -1); len(locs) > 0 {
+				raw := p.raw[i]
+				line = "[-:-:-]"
+				last := 0
+				for _, loc := range locs {
+					line += tview.Escape(raw[last:loc[0]])
+					line += fmt.Sprintf(`["m%d"][black:yellow]%s[-:-][""]`, len(p.matches), tview.Escape(raw[loc[0]:loc[1]]))
+					p.matches = append(p.matches, i)
+					last = loc[1]
+				}
+				line += tview.Escape(raw[last:])
+			}
+		}
+
+		if i == p.gotoLine {
+			line = `["line"]` + line + `[""]`
+		}
+		buf.WriteString(line + "[-:-:-]\n")
+	}
+
+	p.SetWrap(p.wrap)
+	p.SetText(buf.String())
+	p.updateTitle()
+}
+
+func (p *Pager) updateTitle() {
+	var modes []string
+	if p.follow {
+		modes = append(modes, "follow")
+	}
+	if !p.wrap {
+		modes = append(modes, "nowrap")
+	}
+	if p.diff != nil && p.sideBySide {
+		modes = append(modes, "side by side")
+	}
+
+	title := p.title
+	if p.diff != nil {
+		if len(p.diff.hunks) == 0 {
+			title += " [no difference]"
+		} else {
+			title += fmt.Sprintf(" [hunk %d/%d]", p.hunk+1, len(p.diff.hunks))
+		}
+	}
+	if p.searchWord != "" {
+		if len(p.matches) == 0 {
+			title += fmt.Sprintf(" /%s [no match]", p.searchWord)
+		} else {
+			title += fmt.Sprintf(" /%s [%d/%d]", p.searchWord, p.current+1, len(p.matches))
+		}
+	}
+	if p.windowed() {
+		title += fmt.Sprintf(" [%d%%]", p.offset*100/p.entry.Size)
+	}
+	if p.status != "" {
+		title += " [" + p.status + "]"
+	}
+	if len(modes) > 0 {
+		title += " (" + strings.Join(modes, ", ") + ")"
+	}
+	p.SetTitle(title)
+}
+
+// Search highlight the word, and jump to the first match from the top of the view.
+// the rest of the large file is searched if the window has no match
+func (p *Pager) Search(gui *Gui, word string) {
+	p.stopSearch()
+	p.searchWord = word
+	p.gotoLine = -1
+	p.render()
+
+	row, _ := p.GetScrollOffset()
+	_, _, width, _ := p.GetInnerRect()
+	top := p.lineAtRow(row, width)
+	for i, line := range p.matches {
+		if line >= top {
+			p.current = i
+			p.jumpMatch()
+			return
+		}
+	}
+
+	if p.windowed() {
+		p.searchFile(gui, 1)
+		return
+	}
+	p.current = 0
+	p.jumpMatch()
+}
+
+// NextMatch jump to the next match, which may be in the next window
+func (p *Pager) NextMatch(gui *Gui) {
+	if p.windowed() && (len(p.matches) == 0 || p.current == len(p.matches)-1) {
+		p.searchFile(gui, 1)
+		return
+	}
+	if len(p.matches) == 0 {
+		return
+	}
+	p.current = (p.current + 1) % len(p.matches)
+	p.jumpMatch()
+}
+
+// PrevMatch jump to the previous match, which may be in the previous window
+func (p *Pager) PrevMatch(gui *Gui) {
+	if p.windowed() && (len(p.matches) == 0 || p.current == 0) {
+		p.searchFile(gui, -1)
+		return
+	}
+	if len(p.matches) == 0 {
+		return
+	}
+	p.current = (p.current - 1 + len(p.matches)) % len(p.matches)
+	p.jumpMatch()
+}
+
+func (p *Pager) jumpMatch() {
+	p.updateTitle()
+	if len(p.matches) == 0 {
+		p.Highlight()
+		return
+	}
+	p.Highlight(fmt.Sprintf("m%d", p.current)).ScrollToHighlight()
+}
+
+// GotoLine jump to the line, line starts with 1.
+// the line out of the window is found with the index of lines
+func (p *Pager) GotoLine(line int) {
+	if line < 1 {
+		line = 1
+	}
+	line--
+
+	if p.windowed() && (p.firstLine < 0 || line < p.firstLine || line >= p.firstLine+p.lineCount()) {
+		if p.index != nil {
+			if lines, ok := p.index.Lines(); ok && line >= lines {
+				line = lines - 1
+			}
+		}
+		offset, ok := p.offsetOf(line)
+		if !ok {
+			p.status = "lines are not indexed yet"
+			p.updateTitle()
+			return
+		}
+		if err := p.load(offset); err != nil {
+			p.status = err.Error()
+			p.updateTitle()
+			return
+		}
+	}
+
+	if p.firstLine > 0 {
+		line -= p.firstLine
+	}
+	if line >= p.lineCount() {
+		line = p.lineCount() - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+
+	p.stopSearch()
+	p.searchWord = ""
+	p.gotoLine = line
+	p.render()
+	p.Highlight("line").ScrollToHighlight()
+}
+
+// ScrollHalfPage scroll half of the page, direction is 1 or -1
+func (p *Pager) ScrollHalfPage(direction int) {
+	_, _, _, height := p.GetInnerRect()
+	row, col := p.GetScrollOffset()
+	row += direction * height / 2
+	if row < 0 {
+		row = 0
+	}
+	p.ScrollTo(row, col)
+}
+
+func (p *Pager) ToggleLineNumbers() {
+	p.lineNumbers = !p.lineNumbers
+	p.render()
+}
+
+func (p *Pager) ToggleWrap() {
+	p.wrap = !p.wrap
+	p.render()
+}
+
+// ToggleFollow toggle following the growing file like `tail -f`
+func (p *Pager) ToggleFollow(gui *Gui) error {
+	if p.follow {
+		p.stopFollow()
+		p.updateTitle()
+		return nil
+	}
+
+	if p.entry == nil {
+		return nil
+	}
+	if s3.IsPath(p.entry.PathName) {
+		return ErrNotSupported
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.follow = true
+	p.loadTail()
+	p.updateTitle()
+	p.ScrollToEnd()
+
+	go func() {
+		t := time.NewTicker(followInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				gui.App.QueueUpdateDraw(func() {
+					if ctx.Err() != nil {
+						return
+					}
+					p.readAppended(gui)
+				})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *Pager) stopFollow() {
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.follow = false
+}
+
+// readAppended read the appended bytes, and reload the file if it is truncated
+func (p *Pager) readAppended(gui *Gui) {
+	info, err := os.Stat(p.entry.PathName)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	size := info.Size()
+	if size == p.offset {
+		return
+	}
+
+	entry := *p.entry
+	entry.Size = size
+	p.entry = &entry
+
+	// the window is read again if it grows too large, or the file is truncated
+	if size < p.offset || p.binary || size-p.start > 2*pagerWindowSize {
+		if size < p.offset {
+			p.firstLine = -1
+			p.startIndex(gui)
+		}
+		p.load(p.windowBefore(size))
+		p.ScrollToEnd()
+		return
+	}
+
+	b, err := readFileAt(&entry, p.offset, size-p.offset)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for i, c := range b {
+		if c == '\n' {
+			p.starts = append(p.starts, p.offset-p.start+int64(i)+1)
+		}
+	}
+	p.offset += int64(len(b))
+
+	// the last line may be continued
+	for i, text := range strings.Split(string(b), "\n") {
+		if i == 0 {
+			p.lines[len(p.lines)-1] += tview.Escape(text)
+			p.raw[len(p.raw)-1] += text
+			continue
+		}
+		p.lines = append(p.lines, tview.Escape(text))
+		p.raw = append(p.raw, text)
+	}
+
+	p.render()
+	p.ScrollToEnd()
+}
+
+func (p *Pager) prompt(gui *Gui, label, pageName string, done func(text string)) {
+	input := tview.NewInputField().SetLabel(label).SetLabelWidth(len(label) + 1)
+	input.SetBorder(true).SetTitle(pageName).SetTitleAlign(tview.AlignLeft)
+	input.SetDoneFunc(func(key tcell.Key) {
+		gui.Pages.RemovePage(pageName).ShowPage("pager")
+		gui.FocusPanel(PagerPanel)
+		if key == tcell.KeyEnter {
+			done(input.GetText())
+		}
+	})
+
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(input, 0, 3), true).ShowPage("pager")
+}
+
+func (p *Pager) Keybinding(gui *Gui) {
+	p.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			p.Close(gui)
+		}
+	})
+
+	p.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		p.scrollWindow(event)
+		switch event.Key() {
+		case tcell.KeyF1:
+			gui.Help.UpdateView(PagerPanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("pager")
+			return nil
+		case tcell.KeyCtrlD:
+			p.ScrollHalfPage(1)
+			return nil
+		case tcell.KeyCtrlU:
+			p.ScrollHalfPage(-1)
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'q':
+			p.Close(gui)
+		case '?':
+			gui.Help.UpdateView(PagerPanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("pager")
+		case '/':
+			p.prompt(gui, "word", "search", func(text string) {
+				p.Search(gui, text)
+			})
+		case 'n':
+			p.NextMatch(gui)
+		case 'N':
+			p.PrevMatch(gui)
+		case ':':
+			p.prompt(gui, "line", "goto line", func(text string) {
+				var line int
+				if _, err := fmt.Sscan(text, &line); err != nil {
+					return
+				}
+				p.GotoLine(line)
+			})
+		case '#':
+			p.ToggleLineNumbers()
+		case 'w':
+			p.ToggleWrap()
+		case 's':
+			p.ToggleDiffLayout(gui)
+		case ']':
+			p.NextHunk(1)
+		case '[':
+			p.NextHunk(-1)
+		case 'F':
+			if err := p.ToggleFollow(gui); err != nil {
+				log.Println(err)
+				p.SetTitle(p.title + " (" + err.Error() + ")")
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+}
@@ -0,0 +1,617 @@
+package gui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+)
+
+const (
+	// bytes of the file which the pager keeps in memory, larger files are read in windows.
+	// it is a multiple of the width of the hex dump
+	pagerWindowSize = 256 * 1024
+	// offsets of every pagerIndexStep lines are indexed to find lines in large files
+	pagerIndexStep = 1000
+	// bytes to read at once when scanning the file
+	pagerScanSize = 64 * 1024
+)
+
+// lineIndex offsets of every pagerIndexStep lines of the file, which is built in background
+type lineIndex struct {
+	mu sync.Mutex
+	// offsets[i] is the offset of the line i*pagerIndexStep
+	offsets []int64
+	// number of lines when the index is done
+	lines int
+	done  bool
+}
+
+// buildLineIndex index the local file in background, done is called when the index is done
+func buildLineIndex(ctx context.Context, name string, done func()) *lineIndex {
+	x := &lineIndex{offsets: []int64{0}}
+	go func() {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		defer f.Close()
+
+		r := bufio.NewReaderSize(f, pagerScanSize)
+		buf := make([]byte, pagerScanSize)
+		var offset int64
+		var lines int
+		var last byte
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			n, err := r.Read(buf)
+			b := buf[:n]
+			if n > 0 {
+				last = b[n-1]
+			}
+			for {
+				i := bytes.IndexByte(b, '\n')
+				if i == -1 {
+					break
+				}
+				lines++
+				if lines%pagerIndexStep == 0 {
+					x.mu.Lock()
+					x.offsets = append(x.offsets, offset+int64(i)+1)
+					x.mu.Unlock()
+				}
+				offset += int64(i) + 1
+				b = b[i+1:]
+			}
+			offset += int64(len(b))
+
+			if err == io.EOF {
+				// the last line doesn't end with a newline
+				if offset > 0 && last != '\n' {
+					lines++
+				}
+				break
+			}
+			if err != nil {
+				log.Println(err)
+				return
+			}
+		}
+
+		x.mu.Lock()
+		x.lines = lines
+		x.done = true
+		x.mu.Unlock()
+		done()
+	}()
+	return x
+}
+
+// checkpoint get the indexed line before the offset, ok is false if the index hasn't reached the offset
+func (x *lineIndex) checkpoint(offset int64) (line int, start int64, ok bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	k := sort.Search(len(x.offsets), func(i int) bool { return x.offsets[i] > offset }) - 1
+	if k == len(x.offsets)-1 && !x.done {
+		return 0, 0, false
+	}
+	return k * pagerIndexStep, x.offsets[k], true
+}
+
+// lineCheckpoint get the indexed line before the line
+func (x *lineIndex) lineCheckpoint(line int) (int, int64, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	k := line / pagerIndexStep
+	if k >= len(x.offsets) {
+		if !x.done {
+			return 0, 0, false
+		}
+		k = len(x.offsets) - 1
+	}
+	return k * pagerIndexStep, x.offsets[k], true
+}
+
+// Lines get number of lines, ok is false until the index is done
+func (x *lineIndex) Lines() (int, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.lines, x.done
+}
+
+// lineOf get the line number of the line which starts at the offset
+func (p *Pager) lineOf(offset int64) (int, bool) {
+	if p.binary {
+		return int(offset / 16), true
+	}
+	if offset == 0 {
+		return 0, true
+	}
+	if p.index == nil {
+		return 0, false
+	}
+
+	line, start, ok := p.index.checkpoint(offset)
+	if !ok {
+		return 0, false
+	}
+
+	// count the lines from the checkpoint
+	for start < offset {
+		length := offset - start
+		if length > pagerScanSize {
+			length = pagerScanSize
+		}
+		b, err := readFileAt(p.entry, start, length)
+		if err != nil || len(b) == 0 {
+			return 0, false
+		}
+		line += bytes.Count(b, []byte{'\n'})
+		start += int64(len(b))
+	}
+	return line, true
+}
+
+// offsetOf get the offset of the line
+func (p *Pager) offsetOf(line int) (int64, bool) {
+	if p.binary {
+		offset := int64(line) * 16
+		if offset >= p.entry.Size {
+			offset = (p.entry.Size - 1) / 16 * 16
+		}
+		if offset < 0 {
+			offset = 0
+		}
+		return offset, true
+	}
+	if line == 0 {
+		return 0, true
+	}
+	if p.index == nil {
+		return 0, false
+	}
+
+	from, offset, ok := p.index.lineCheckpoint(line)
+	if !ok {
+		return 0, false
+	}
+
+	// skip the lines from the checkpoint
+	rest := line - from
+	for rest > 0 {
+		b, err := readFileAt(p.entry, offset, pagerScanSize)
+		if err != nil || len(b) == 0 {
+			return 0, false
+		}
+		for rest > 0 {
+			i := bytes.IndexByte(b, '\n')
+			if i == -1 {
+				break
+			}
+			rest--
+			offset += int64(i) + 1
+			b = b[i+1:]
+		}
+		if rest > 0 {
+			offset += int64(len(b))
+		}
+	}
+	return offset, true
+}
+
+// windowed return true if only a part of the file is in memory
+func (p *Pager) windowed() bool {
+	return p.entry != nil && (p.start > 0 || p.offset < p.entry.Size)
+}
+
+// readWindow read the window from the offset, which is cut at the end of the line or the line of hex dump
+func (p *Pager) readWindow(from int64) ([]byte, error) {
+	b, err := readFileAt(p.entry, from, pagerWindowSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < pagerWindowSize || p.binary {
+		return b, nil
+	}
+	// a line longer than the window is split
+	if i := bytes.LastIndexByte(b, '\n'); i != -1 {
+		return b[:i+1], nil
+	}
+	return b, nil
+}
+
+// windowBefore get the start of the window which ends at the offset
+func (p *Pager) windowBefore(end int64) int64 {
+	start := end - pagerWindowSize
+	if start <= 0 {
+		return 0
+	}
+	if p.binary {
+		return (start + 15) / 16 * 16
+	}
+
+	// start from the next line
+	b, err := readFileAt(p.entry, start, end-start)
+	if err != nil {
+		log.Println(err)
+		return start
+	}
+	if i := bytes.IndexByte(b, '\n'); i != -1 && start+int64(i)+1 < end {
+		return start + int64(i) + 1
+	}
+	return start
+}
+
+// lineStarts offsets of lines in b, the trailing empty line is included like strings.Split
+func lineStarts(b []byte, binary bool) []int64 {
+	starts := []int64{0}
+	if binary {
+		for i := 16; i < len(b); i += 16 {
+			starts = append(starts, int64(i))
+		}
+		if len(b) > 0 {
+			starts = append(starts, int64(len(b)))
+		}
+		return starts
+	}
+
+	for i, c := range b {
+		if c == '\n' {
+			starts = append(starts, int64(i)+1)
+		}
+	}
+	return starts
+}
+
+// load read the window from the offset, the first line of the window is shown at the top
+func (p *Pager) load(from int64) error {
+	b, err := p.readWindow(from)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	starts := lineStarts(b, p.binary)
+
+	// the line number is known if the windows overlap
+	first := -1
+	if p.firstLine >= 0 {
+		if i := p.lineAt(from); i != -1 {
+			first = p.firstLine + i
+		} else if i := searchOffset(starts, p.start-from); i != -1 {
+			first = p.firstLine - i
+		}
+	}
+	if first == -1 {
+		if line, ok := p.lineOf(from); ok {
+			first = line
+		}
+	}
+
+	p.start = from
+	p.offset = from + int64(len(b))
+	p.starts = starts
+	p.firstLine = first
+	p.setLines(p.format(b, from))
+	p.ScrollToBeginning()
+	return nil
+}
+
+// searchOffset get the index of the offset in starts, or -1
+func searchOffset(starts []int64, offset int64) int {
+	i := sort.Search(len(starts), func(i int) bool { return starts[i] >= offset })
+	if i < len(starts) && starts[i] == offset {
+		return i
+	}
+	return -1
+}
+
+// lineAt get the line in memory which starts at the offset of the file, or -1
+func (p *Pager) lineAt(offset int64) int {
+	if offset < p.start {
+		return -1
+	}
+	return searchOffset(p.starts, offset-p.start)
+}
+
+// rows get the number of rows which the line takes in the view, prefix is the width of the line number
+func (p *Pager) rows(line, width, prefix int) int {
+	if !p.wrap || width <= 0 {
+		return 1
+	}
+	w := textWidth(p.raw[line]) + prefix
+	if w <= width {
+		return 1
+	}
+	return (w + width - 1) / width
+}
+
+// textWidth width of the text, ascii text is measured without parsing it
+func textWidth(text string) int {
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; c >= utf8.RuneSelf || c == '\t' {
+			return tview.TaggedStringWidth(tview.Escape(text))
+		}
+	}
+	return len(text)
+}
+
+// prefixWidth width of the line number before lines
+func (p *Pager) prefixWidth() int {
+	if !p.lineNumbers {
+		return 0
+	}
+	return p.digits() + 1
+}
+
+// lineAtRow get the line which is shown at the row of the view
+func (p *Pager) lineAtRow(row, width int) int {
+	if !p.wrap {
+		if row >= len(p.raw) {
+			return len(p.raw) - 1
+		}
+		return row
+	}
+	prefix := p.prefixWidth()
+	for i := range p.raw {
+		row -= p.rows(i, width, prefix)
+		if row < 0 {
+			return i
+		}
+	}
+	return len(p.raw) - 1
+}
+
+// rowOfLine get the row of the view where the line is shown
+func (p *Pager) rowOfLine(line, width int) int {
+	if !p.wrap {
+		return line
+	}
+	prefix := p.prefixWidth()
+	var row int
+	for i := 0; i < line && i < len(p.raw); i++ {
+		row += p.rows(i, width, prefix)
+	}
+	return row
+}
+
+// moveWindow read the next or previous window when the view is at the edge of the window,
+// direction is 1 or -1
+func (p *Pager) moveWindow(direction int) {
+	if !p.windowed() {
+		return
+	}
+	row, col := p.GetScrollOffset()
+	_, _, width, height := p.GetInnerRect()
+
+	if direction > 0 {
+		if p.offset >= p.entry.Size || p.rowOfLine(p.lineCount(), width) > row+height {
+			return
+		}
+		// continue from the line at the top of the view
+		line := p.lineAtRow(row, width)
+		if line == 0 {
+			line = len(p.starts) - 1
+		}
+		if err := p.load(p.start + p.starts[line]); err != nil {
+			return
+		}
+		p.ScrollTo(0, col)
+		return
+	}
+
+	if p.start == 0 || row > 0 {
+		return
+	}
+	old := p.start
+	if err := p.load(p.windowBefore(old)); err != nil {
+		return
+	}
+	if line := p.lineAt(old); line != -1 {
+		p.ScrollTo(p.rowOfLine(line, width), col)
+	}
+}
+
+// scrollWindow read another window before the view is scrolled out of the window by the key
+func (p *Pager) scrollWindow(event *tcell.EventKey) {
+	if !p.windowed() {
+		return
+	}
+	switch event.Key() {
+	case tcell.KeyDown, tcell.KeyPgDn, tcell.KeyCtrlD, tcell.KeyCtrlF:
+		p.moveWindow(1)
+	case tcell.KeyUp, tcell.KeyPgUp, tcell.KeyCtrlU, tcell.KeyCtrlB:
+		p.moveWindow(-1)
+	case tcell.KeyHome:
+		if p.start > 0 {
+			p.load(0)
+		}
+	case tcell.KeyEnd:
+		p.loadTail()
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'j':
+			p.moveWindow(1)
+		case 'k':
+			p.moveWindow(-1)
+		case 'g':
+			if p.start > 0 {
+				p.load(0)
+			}
+		case 'G':
+			p.loadTail()
+		}
+	}
+}
+
+// loadTail read the last window of the file
+func (p *Pager) loadTail() {
+	if p.offset >= p.entry.Size && p.start == 0 {
+		return
+	}
+	p.load(p.windowBefore(p.entry.Size))
+}
+
+// scanMatch find the line which matches the pattern between from and to in background,
+// the last match is found if last is true. found is called with the offset of the line, or -1
+func (p *Pager) scanMatch(ctx context.Context, pattern *regexp.Regexp, from, to int64, last bool, found func(offset int64)) {
+	entry := *p.entry
+	go func() {
+		match := int64(-1)
+		for from < to && ctx.Err() == nil {
+			length := to - from
+			if length > pagerWindowSize {
+				length = pagerWindowSize
+			}
+			b, err := readFileAt(&entry, from, length)
+			if err != nil {
+				log.Println(err)
+				break
+			}
+			if len(b) == 0 {
+				break
+			}
+			// the cut off line is read with the next chunk
+			if int64(len(b)) == pagerWindowSize {
+				if i := bytes.LastIndexByte(b, '\n'); i != -1 {
+					b = b[:i+1]
+				}
+			}
+
+			var start int
+			for start < len(b) {
+				end := bytes.IndexByte(b[start:], '\n')
+				if end == -1 {
+					end = len(b)
+				} else {
+					end += start
+				}
+				if pattern.Match(b[start:end]) {
+					match = from + int64(start)
+					if !last {
+						break
+					}
+				}
+				start = end + 1
+			}
+			if match != -1 && !last {
+				break
+			}
+			from += int64(len(b))
+		}
+
+		if ctx.Err() == nil {
+			found(match)
+		}
+	}()
+}
+
+// searchFile find the next or previous match out of the window in background,
+// direction is 1 or -1. the search wraps around the file
+func (p *Pager) searchFile(gui *Gui, direction int) {
+	if p.binary || p.searchWord == "" {
+		p.updateTitle()
+		return
+	}
+	p.stopSearch()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.searchCancel = cancel
+
+	pattern := p.searchPattern()
+	start, end, size := p.start, p.offset, p.entry.Size
+	p.status = "searching"
+	p.updateTitle()
+
+	show := func(offset int64) {
+		gui.App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			p.status = ""
+			if offset == -1 {
+				p.updateTitle()
+				return
+			}
+			if err := p.load(offset); err != nil {
+				p.status = err.Error()
+				p.updateTitle()
+				return
+			}
+			// the first line of the window matches
+			p.current = 0
+			if direction < 0 {
+				for i, line := range p.matches {
+					if line == 0 {
+						p.current = i
+					}
+				}
+			}
+			p.jumpMatch()
+		})
+	}
+
+	if direction > 0 {
+		p.scanMatch(ctx, pattern, end, size, false, func(offset int64) {
+			if offset == -1 {
+				p.scanMatch(ctx, pattern, 0, start, false, show)
+				return
+			}
+			show(offset)
+		})
+		return
+	}
+	p.scanMatch(ctx, pattern, 0, start, true, func(offset int64) {
+		if offset == -1 {
+			p.scanMatch(ctx, pattern, end, size, true, show)
+			return
+		}
+		show(offset)
+	})
+}
+
+func (p *Pager) stopSearch() {
+	if p.searchCancel != nil {
+		p.searchCancel()
+		p.searchCancel = nil
+	}
+	p.status = ""
+}
+
+// startIndex index lines of the large local file to show line numbers and to go to lines
+func (p *Pager) startIndex(gui *Gui) {
+	if p.indexCancel != nil {
+		p.indexCancel()
+	}
+	p.index = nil
+	if p.binary || p.entry.Size <= pagerWindowSize || s3.IsPath(p.entry.PathName) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.indexCancel = cancel
+	p.index = buildLineIndex(ctx, p.entry.PathName, func() {
+		gui.App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || p.firstLine != -1 {
+				return
+			}
+			if line, ok := p.lineOf(p.start); ok {
+				p.firstLine = line
+				p.render()
+			}
+		})
+	})
+}
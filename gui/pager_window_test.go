@@ -0,0 +1,189 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLines create the file with lines "line 1", "line 2", ...
+func writeLines(t *testing.T, dir string, n int) *File {
+	t.Helper()
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	writeTestContents(t, dir, map[string]string{"lines.txt": b.String()})
+	path := filepath.Join(dir, "lines.txt")
+	return &File{Name: "lines.txt", Path: dir, PathName: path, Size: int64(b.Len())}
+}
+
+func waitIndex(t *testing.T, entry *File) *lineIndex {
+	t.Helper()
+	done := make(chan struct{})
+	x := buildLineIndex(context.Background(), entry.PathName, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the index isn't done")
+	}
+	return x
+}
+
+func TestLineStarts(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      string
+		binary bool
+		want   []int64
+	}{
+		{"empty", "", false, []int64{0}},
+		{"no newline", "abc", false, []int64{0}},
+		{"lines", "a\nbc\n", false, []int64{0, 2, 5}},
+		{"last line", "a\nbc", false, []int64{0, 2}},
+		{"binary", strings.Repeat("x", 40), true, []int64{0, 16, 32, 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineStarts([]byte(tt.b), tt.binary); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineStarts(%q) = %v, want %v", tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineIndex(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	entry := writeLines(t, dir, 3*pagerIndexStep+10)
+
+	p := &Pager{entry: entry, index: waitIndex(t, entry)}
+	if lines, ok := p.index.Lines(); !ok || lines != 3*pagerIndexStep+10 {
+		t.Fatalf("Lines() = %d, %v", lines, ok)
+	}
+
+	for _, line := range []int{0, 1, pagerIndexStep - 1, pagerIndexStep, 2*pagerIndexStep + 5, 3*pagerIndexStep + 9} {
+		offset, ok := p.offsetOf(line)
+		if !ok {
+			t.Fatalf("offsetOf(%d) isn't found", line)
+		}
+		b, err := readFileAt(entry, offset, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("line %d\n", line+1); !strings.HasPrefix(string(b), want) {
+			t.Errorf("line at offsetOf(%d) = %q, want %q", line, b, want)
+		}
+		if got, ok := p.lineOf(offset); !ok || got != line {
+			t.Errorf("lineOf(%d) = %d, %v, want %d", offset, got, ok, line)
+		}
+	}
+}
+
+func TestPagerWindow(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	entry := writeLines(t, dir, 100000)
+
+	p := NewPager(PreviewConfig{}, false)
+	p.entry = entry
+	p.index = waitIndex(t, entry)
+
+	if err := p.load(0); err != nil {
+		t.Fatal(err)
+	}
+	if !p.windowed() {
+		t.Fatal("the large file should be read in windows")
+	}
+	if p.offset-p.start > pagerWindowSize {
+		t.Errorf("window size = %d, want <= %d", p.offset-p.start, pagerWindowSize)
+	}
+	// the window ends at the end of a line
+	if last := p.raw[len(p.raw)-1]; last != "" {
+		t.Errorf("the window ends with %q", last)
+	}
+
+	// the next window starts at a known line
+	next := p.start + p.starts[len(p.starts)-2]
+	line := p.firstLine + len(p.starts) - 2
+	if err := p.load(next); err != nil {
+		t.Fatal(err)
+	}
+	if p.firstLine != line {
+		t.Errorf("firstLine = %d, want %d", p.firstLine, line)
+	}
+	if want := fmt.Sprintf("line %d", line+1); p.raw[0] != want {
+		t.Errorf("first line = %q, want %q", p.raw[0], want)
+	}
+
+	// the previous window ends at the start of this one
+	start := p.windowBefore(p.start)
+	b, err := readFileAt(entry, start, p.start-start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start > 0 && b[len(b)-1] != '\n' {
+		t.Errorf("the previous window doesn't end at the end of a line")
+	}
+	if before, _ := readFileAt(entry, start-1, 1); start > 0 && before[0] != '\n' {
+		t.Errorf("the previous window doesn't start at a line")
+	}
+
+	p.loadTail()
+	if p.offset != entry.Size {
+		t.Errorf("offset of the tail = %d, want %d", p.offset, entry.Size)
+	}
+	if want := "line 100000"; p.raw[len(p.raw)-2] != want {
+		t.Errorf("last line = %q, want %q", p.raw[len(p.raw)-2], want)
+	}
+}
+
+func TestScanMatch(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	entry := writeLines(t, dir, 100000)
+	p := &Pager{entry: entry}
+
+	scan := func(expr string, from, to int64, last bool) string {
+		found := make(chan int64, 1)
+		p.scanMatch(context.Background(), regexp.MustCompile(expr), from, to, last, func(offset int64) {
+			found <- offset
+		})
+		offset := <-found
+		if offset == -1 {
+			return ""
+		}
+		b, err := readFileAt(entry, offset, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.SplitN(string(b), "\n", 2)[0]
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from, to int64
+		last     bool
+		want     string
+	}{
+		{"first", "line 9999", 0, entry.Size, false, "line 9999"},
+		{"last", "line 9999", 0, entry.Size, true, "line 99999"},
+		{"after", "line 5000", 100, entry.Size, false, "line 5000"},
+		{"out of range", "line 99999", 0, entry.Size / 2, false, ""},
+		{"no match", "not found", 0, entry.Size, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scan(tt.expr, tt.from, tt.to, tt.last); got != tt.want {
+				t.Errorf("match = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
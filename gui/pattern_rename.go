@@ -0,0 +1,252 @@
+package gui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tokens in the replacement, e.g. {n}, {n:3}, {modified:20060102}
+var renameTokenRegexp = regexp.MustCompile(`\{(n|name|ext|modified|created|accessed)(?::([^}]*))?\}`)
+
+// width of the counter like 3, which is padded with zeros. 03 is the same as 3
+var counterSpecRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// default layout of the date tokens
+const renameDateFmt = "2006-01-02"
+
+var renameCases = []string{"keep", "lower", "upper", "title"}
+
+// renamePattern batch rename rule
+type renamePattern struct {
+	find    *regexp.Regexp
+	replace string
+	caseTo  string
+	ext     string
+}
+
+func formatDate(date, layout string) string {
+	if date == "" {
+		return ""
+	}
+	t, err := time.ParseInLocation(dateFmt, date, time.Local)
+	if err != nil {
+		return ""
+	}
+	if layout == "" {
+		layout = renameDateFmt
+	}
+	return t.Format(layout)
+}
+
+// expandTokens replace tokens with values of the file and the counter
+func expandTokens(text string, f *File, n int) string {
+	return renameTokenRegexp.ReplaceAllStringFunc(text, func(token string) string {
+		m := renameTokenRegexp.FindStringSubmatch(token)
+		name, spec := m[1], m[2]
+
+		switch name {
+		case "n":
+			if counterSpecRegexp.MatchString(spec) {
+				width, _ := strconv.Atoi(spec)
+				return fmt.Sprintf("%0*d", width, n)
+			}
+			return strconv.Itoa(n)
+		case "name":
+			return strings.TrimSuffix(f.Name, filepath.Ext(f.Name))
+		case "ext":
+			return filepath.Ext(f.Name)
+		case "modified":
+			return formatDate(f.Change, spec)
+		case "created":
+			return formatDate(f.Create, spec)
+		case "accessed":
+			return formatDate(f.Access, spec)
+		}
+		return token
+	})
+}
+
+func convertCase(name, caseTo string) string {
+	switch caseTo {
+	case "lower":
+		return strings.ToLower(name)
+	case "upper":
+		return strings.ToUpper(name)
+	case "title":
+		return strings.Title(strings.ToLower(name))
+	}
+	return name
+}
+
+// Names make new names of the files, files which don't match find keep their names
+func (p renamePattern) Names(files []*File) []string {
+	var names []string
+	n := 1
+	for _, f := range files {
+		name := f.Name
+		switch {
+		case p.find != nil && p.find.MatchString(name):
+			// $ in token values must not be treated as capture groups
+			template := renameTokenRegexp.ReplaceAllStringFunc(p.replace, func(token string) string {
+				return strings.Replace(expandTokens(token, f, n), "$", "$$", -1)
+			})
+			name = p.find.ReplaceAllString(name, template)
+			n++
+		case p.find == nil && p.replace != "":
+			name = expandTokens(p.replace, f, n)
+			n++
+		case p.find != nil:
+			names = append(names, name)
+			continue
+		}
+
+		name = convertCase(name, p.caseTo)
+		if p.ext != "" && !f.IsDir {
+			name = strings.TrimSuffix(name, filepath.Ext(name)) + "." + strings.TrimPrefix(p.ext, ".")
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// PatternRename rename the marked files or all files in the current directory with the pattern,
+// the new names are previewed while typing
+func (gui *Gui) PatternRename() error {
+	files, err := gui.renameFiles()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	pageName := "pattern_rename"
+	closeDialog := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+
+	preview := tview.NewTable().SetFixed(1, 0)
+	preview.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+
+	form := tview.NewForm().SetItemPadding(0).
+		AddInputField("find", "", 0, nil, nil).
+		AddInputField("replace", "", 0, nil, nil).
+		AddDropDown("case", renameCases, 0, nil).
+		AddInputField("extension", "", 0, nil, nil)
+	form.SetBorder(true).SetTitle("rename: $1 {n:3} {name} {ext} {modified:20060102}").SetTitleAlign(tview.AlignLeft)
+
+	find := form.GetFormItemByLabel("find").(*tview.InputField)
+	replace := form.GetFormItemByLabel("replace").(*tview.InputField)
+	caseTo := form.GetFormItemByLabel("case").(*tview.DropDown)
+	ext := form.GetFormItemByLabel("extension").(*tview.InputField)
+
+	// pattern from the form, nil if the regexp is invalid
+	pattern := func() *renamePattern {
+		p := &renamePattern{
+			replace: replace.GetText(),
+			ext:     ext.GetText(),
+		}
+		_, p.caseTo = caseTo.GetCurrentOption()
+		if text := find.GetText(); text != "" {
+			re, err := regexp.Compile(text)
+			if err != nil {
+				return nil
+			}
+			p.find = re
+		}
+		return p
+	}
+
+	update := func() {
+		preview.Clear()
+		for i, h := range []string{"Old", "New", "Error"} {
+			preview.SetCell(0, i, &tview.TableCell{
+				Text:            h,
+				NotSelectable:   true,
+				Align:           tview.AlignLeft,
+				Color:           tcell.ColorYellow,
+				BackgroundColor: tcell.ColorDefault,
+			})
+		}
+
+		p := pattern()
+		if p == nil {
+			preview.SetTitle("[red]invalid regexp[-]")
+			return
+		}
+
+		names := p.Names(files)
+		errs := checkRenames(files, names)
+		var changes, conflicts int
+		for i, f := range files {
+			color := tcell.ColorWhite
+			var message string
+			switch {
+			case errs[i] != nil:
+				color = tcell.ColorRed
+				message = errs[i].Error()
+				conflicts++
+			case names[i] != f.Name:
+				color = tcell.ColorGreen
+				changes++
+			}
+			preview.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(f.Name)).SetTextColor(color))
+			preview.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(names[i])).SetTextColor(color))
+			preview.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(message)).SetTextColor(color))
+		}
+		preview.SetTitle(fmt.Sprintf("preview: %d changes, %d conflicts", changes, conflicts))
+	}
+
+	for _, input := range []*tview.InputField{find, replace, ext} {
+		input.SetChangedFunc(func(string) {
+			update()
+		})
+	}
+	caseTo.SetSelectedFunc(func(string, int) {
+		update()
+	})
+
+	form.AddButton("rename", func() {
+		p := pattern()
+		if p == nil {
+			return
+		}
+
+		ops, err := planRenames(files, p.Names(files))
+		if err == nil {
+			err = applyRenames(ops)
+		}
+		if err != nil {
+			log.Println(err)
+			gui.Pages.RemovePage(pageName)
+			gui.Message(err.Error(), FileTablePanel)
+			gui.FileBrowser.UpdateView()
+			return
+		}
+
+		marks.Clear()
+		gui.FileBrowser.UpdateView()
+		closeDialog()
+	}).
+		AddButton("cancel", closeDialog).
+		SetCancelFunc(closeDialog)
+
+	update()
+
+	dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 10, 0, true).
+		AddItem(preview, 0, 1, false)
+
+	rows := len(files)
+	if rows > maxRenameSummary {
+		rows = maxRenameSummary
+	}
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(dialog, 80, rows+13), true).ShowPage("main")
+	return nil
+}
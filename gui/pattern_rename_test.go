@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestExpandTokens(t *testing.T) {
+	f := &File{Name: "photo.jpg", Change: "2021-03-04 05:06:07"}
+
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"{n}", 7, "7"},
+		{"{n:3}", 7, "007"},
+		{"{n:03}", 7, "007"},
+		{"{n:2}", 123, "123"},
+		{"{n:x}", 7, "7"},
+		{"{name}{ext}", 1, "photo.jpg"},
+		{"{modified}", 1, "2021-03-04"},
+		{"{modified:20060102}", 1, "20210304"},
+		{"{created}", 1, ""},
+		{"{unknown}", 1, "{unknown}"},
+		{"{name}_{n:2}{ext}", 3, "photo_03.jpg"},
+	}
+	for _, tt := range tests {
+		if got := expandTokens(tt.text, f, tt.n); got != tt.want {
+			t.Errorf("expandTokens(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRenamePatternNames(t *testing.T) {
+	files := []*File{
+		{Name: "IMG_1.JPG"},
+		{Name: "note.txt"},
+		{Name: "IMG_2.JPG"},
+		{Name: "dir", IsDir: true},
+	}
+
+	tests := []struct {
+		name    string
+		pattern renamePattern
+		want    []string
+	}{
+		{
+			name:    "find and replace",
+			pattern: renamePattern{find: regexp.MustCompile(`^IMG_(\d+)`), replace: "photo_{n:2}_$1"},
+			want:    []string{"photo_01_1.JPG", "note.txt", "photo_02_2.JPG", "dir"},
+		},
+		{
+			name:    "replace all",
+			pattern: renamePattern{replace: "{n:3}{ext}"},
+			want:    []string{"001.JPG", "002.txt", "003.JPG", "004"},
+		},
+		{
+			name:    "case and extension",
+			pattern: renamePattern{caseTo: "lower", ext: "jpeg"},
+			want:    []string{"img_1.jpeg", "note.jpeg", "img_2.jpeg", "dir"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pattern.Names(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Names() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// $ in the token value is not a group of find
+	p := renamePattern{find: regexp.MustCompile(`^a`), replace: "{name}"}
+	if got, want := p.Names([]*File{{Name: "a$1.txt"}}), []string{"a$1$1.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %q, want %q", got, want)
+	}
+}
@@ -2,29 +2,130 @@ package gui
 
 import (
 	"bytes"
-	"io/ioutil"
+	"context"
+	"fmt"
 	"log"
-	"os/exec"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma"
 	"github.com/alecthomas/chroma/formatters"
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/styles"
 	"github.com/rivo/tview"
+	"github.com/skanehira/ff/git"
+	"github.com/skanehira/ff/s3"
 )
 
+const (
+	defaultMaxPreviewSize = 200000
+	defaultHeadLines      = 1000
+	defaultHeadBytes      = 256 * 1024
+	defaultCacheSize      = 64
+	defaultHandlerTimeout = 3 * time.Second
+	defaultHandlerMaxSize = 1024 * 1024
+
+	// wait for the cursor to stop before rendering
+	previewDelay = 100 * time.Millisecond
+)
+
+// stream the file which is previewed partially.
+// the rest of file is loaded lazily when scrolling.
+type stream struct {
+	entry  *File
+	offset int64
+	// bytes to read at once
+	chunk int64
+	// cut chunk at the end of line
+	lines  bool
+	format func(b []byte, offset int64) string
+	eof    bool
+}
+
+// rendered preview which is cached
+type rendered struct {
+	text   string
+	stream *stream
+}
+
+// previewOptions options which change the rendered preview
+type previewOptions struct {
+	width          int
+	height         int
+	hexMode        bool
+	markdownSource bool
+	showHidden     bool
+	hideIgnored    bool
+	gitDiff        bool
+}
+
 type Preview struct {
 	*tview.TextView
-	colorscheme string
-	lineOffset  int
+	colorscheme    string
+	maxSize        int64
+	headOnly       bool
+	headLines      int
+	headBytes      int64
+	handlers       []PreviewHandler
+	handlerTimeout time.Duration
+	handlerMaxSize int64
+	dirDepth       int
+	dirMaxEntries  int
+	showHidden     bool
+	hideIgnored    bool
+	lineOffset     int
+	hexMode        bool
+	markdownSource bool
+	gitDiff        bool
+	stream         *stream
+	cache          *lruCache
+	cancel         context.CancelFunc
 }
 
-func NewPreview(colorscheme string) *Preview {
+func NewPreview(config PreviewConfig, showHidden bool) *Preview {
 	p := &Preview{
-		TextView:    tview.NewTextView(),
-		colorscheme: colorscheme,
+		TextView:       tview.NewTextView(),
+		colorscheme:    config.Colorscheme,
+		maxSize:        config.MaxSize,
+		headOnly:       config.HeadOnly,
+		headLines:      config.HeadLines,
+		headBytes:      config.HeadBytes,
+		handlers:       config.Handlers,
+		handlerTimeout: config.HandlerTimeout,
+		handlerMaxSize: config.HandlerMaxSize,
+		dirDepth:       config.DirDepth,
+		dirMaxEntries:  config.DirMaxEntries,
+		showHidden:     showHidden,
+	}
+
+	cacheSize := config.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = defaultCacheSize
+	}
+	p.cache = newLRUCache(cacheSize)
+
+	if p.maxSize <= 0 {
+		p.maxSize = defaultMaxPreviewSize
+	}
+	if p.headLines <= 0 {
+		p.headLines = defaultHeadLines
+	}
+	if p.headBytes <= 0 {
+		p.headBytes = defaultHeadBytes
+	}
+	if p.handlerTimeout <= 0 {
+		p.handlerTimeout = defaultHandlerTimeout
+	}
+	if p.handlerMaxSize <= 0 {
+		p.handlerMaxSize = defaultHandlerMaxSize
+	}
+	if p.dirDepth <= 0 {
+		p.dirDepth = defaultDirDepth
+	}
+	if p.dirMaxEntries <= 0 {
+		p.dirMaxEntries = defaultDirMaxEntries
 	}
 
 	p.SetBorder(true).SetTitle("preview").SetTitleAlign(tview.AlignLeft)
@@ -37,59 +138,277 @@ func (p *Preview) UpdateView(g *Gui, entry *File) {
 		return
 	}
 
-	p.lineOffset = 0
+	// cancel the stale rendering
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
 
-	var text string
-	// TODO configrable max file size with option
-	// max size is 2MB
-	if entry.Size > 200000 && !entry.IsDir {
-		text = "file too big"
-	} else if !entry.IsDir {
-		text = p.Highlight(entry)
-	} else {
-		text = p.dirEntry(entry.PathName)
+	_, _, width, height := p.GetInnerRect()
+	opts := previewOptions{
+		width:          width,
+		height:         height,
+		hexMode:        p.hexMode,
+		markdownSource: p.markdownSource,
+		showHidden:     p.showHidden,
+		hideIgnored:    p.hideIgnored,
+		gitDiff:        p.gitDiff,
 	}
-	go g.App.QueueUpdateDraw(func() {
-		p.SetText(text).ScrollToBeginning()
-	})
+
+	go func() {
+		key := p.cacheKey(entry, opts)
+		cached := cacheable(entry, opts)
+		if v, ok := p.cache.Get(key); ok && cached {
+			p.apply(ctx, g, v.(rendered))
+			return
+		}
+
+		select {
+		case <-time.After(previewDelay):
+		case <-ctx.Done():
+			return
+		}
+
+		r := p.render(ctx, entry, opts)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if cached {
+			p.cache.Add(key, r)
+		}
+		p.apply(ctx, g, r)
+	}()
+}
+
+// cacheable return false if the preview depends on more than the file.
+// git diff changes when the file is staged or committed, which the cache key doesn't know
+func cacheable(entry *File, opts previewOptions) bool {
+	return !opts.gitDiff || entry.IsDir || opts.hexMode || s3.IsPath(entry.PathName)
+}
+
+// cacheKey make cache key from path and modified time
+func (p *Preview) cacheKey(entry *File, opts previewOptions) string {
+	mtime := entry.Change
+	size := entry.Size
+	if !s3.IsPath(entry.PathName) {
+		if info, err := os.Stat(entry.PathName); err == nil {
+			mtime = info.ModTime().String()
+			size = info.Size()
+		}
+	}
+
+	// image, hex dump and directory tree depend on the panel size
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%+v", entry.PathName, mtime, size, opts)
+}
+
+func (p *Preview) render(ctx context.Context, entry *File, opts previewOptions) rendered {
+	var r rendered
+	if entry.IsDir {
+		r.text = p.dirEntry(ctx, entry.PathName, opts)
+		return r
+	}
+	if opts.hexMode {
+		r.text, r.stream = p.HexDump(entry, opts.width, opts.height)
+		return r
+	}
+
+	// unchanged file shows the contents
+	if opts.gitDiff && !s3.IsPath(entry.PathName) {
+		if diff, err := git.Diff(ctx, entry.PathName); err == nil && diff != "" {
+			r.text = colorDiff(diff)
+			return r
+		}
+	}
+
+	// built-in preview is the fallback of external previewers
+	if text, ok := p.runHandler(ctx, entry); ok {
+		r.text = text
+		return r
+	}
+
+	switch {
+	case isImage(entry.Name):
+		r.text = p.Image(ctx, entry, opts.width, opts.height)
+	case p.isBinary(entry):
+		r.text, r.stream = p.HexDump(entry, opts.width, opts.height)
+	case p.headOnly || entry.Size > p.maxSize:
+		r.text, r.stream = p.Head(entry)
+	case isMarkdown(entry.Name) && !opts.markdownSource:
+		r.text = p.Markdown(ctx, entry, opts.width)
+	default:
+		r.text = p.Highlight(ctx, entry)
+	}
+	return r
 }
 
-func (p *Preview) dirEntry(path string) string {
-	if _, err := exec.LookPath("tree"); err == nil {
-		out, err := exec.Command("tree", path).CombinedOutput()
-		if err != nil {
-			return err.Error()
+// apply set rendered text to the view if the rendering is not canceled
+func (p *Preview) apply(ctx context.Context, g *Gui, r rendered) {
+	g.App.QueueUpdateDraw(func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.lineOffset = 0
+		p.stream = nil
+		// the cached stream must not be advanced
+		if r.stream != nil && !r.stream.eof {
+			s := *r.stream
+			p.stream = &s
 		}
-		return string(out)
+		p.SetText(r.text).ScrollToBeginning()
+	})
+}
+
+func (p *Preview) updateTitle() {
+	var modes []string
+	if p.hexMode {
+		modes = append(modes, "hex")
+	}
+	if p.markdownSource {
+		modes = append(modes, "markdown source")
+	}
+	if p.gitDiff {
+		modes = append(modes, "git diff")
 	}
 
-	files, err := ioutil.ReadDir(path)
+	title := "preview"
+	if len(modes) > 0 {
+		title += " (" + strings.Join(modes, ", ") + ")"
+	}
+	p.SetTitle(title)
+}
+
+// ToggleHexMode toggle whether to show any file as hex dump
+func (p *Preview) ToggleHexMode(g *Gui, entry *File) {
+	p.hexMode = !p.hexMode
+	p.updateTitle()
+	p.UpdateView(g, entry)
+}
+
+// ToggleMarkdownSource toggle rendered and source view of markdown
+func (p *Preview) ToggleMarkdownSource(g *Gui, entry *File) {
+	p.markdownSource = !p.markdownSource
+	p.updateTitle()
+	p.UpdateView(g, entry)
+}
+
+func (p *Preview) SetShowHidden(show bool) {
+	p.showHidden = show
+}
+
+func (p *Preview) SetHideIgnored(hide bool) {
+	p.hideIgnored = hide
+}
+
+// ToggleGitDiff toggle whether to show the git diff of changed files
+func (p *Preview) ToggleGitDiff(g *Gui, entry *File) {
+	p.gitDiff = !p.gitDiff
+	p.updateTitle()
+	p.UpdateView(g, entry)
+}
+
+func (p *Preview) isBinary(entry *File) bool {
+	b, err := readFileAt(entry, 0, sniffLen)
 	if err != nil {
 		log.Println(err)
+		return false
+	}
+	return isBinary(b)
+}
+
+// Head read the head of file, and the rest is loaded when scrolling
+func (p *Preview) Head(entry *File) (string, *stream) {
+	s := &stream{
+		entry: entry,
+		chunk: p.headBytes,
+		lines: true,
+		format: func(b []byte, offset int64) string {
+			// chunks are read when scrolling, which can't be canceled
+			return highlightCode(context.Background(), p.colorscheme, entry.Name, string(b))
+		},
+	}
+	return p.readChunk(s), s
+}
+
+// readChunk read next chunk of the stream
+func (p *Preview) readChunk(s *stream) string {
+	b, err := readFileAt(s.entry, s.offset, s.chunk)
+	if err != nil {
+		log.Println(err)
+		s.eof = true
 		return err.Error()
 	}
 
-	var contents []string
-	for _, f := range files {
-		contents = append(contents, f.Name())
+	if s.lines {
+		var count, end int
+		for end < len(b) && count < p.headLines {
+			i := bytes.IndexByte(b[end:], '\n')
+			if i == -1 {
+				// keep the cut off line for the next chunk
+				if int64(len(b)) == s.chunk && end > 0 {
+					b = b[:end]
+				}
+				break
+			}
+			end += i + 1
+			count++
+		}
+		if count == p.headLines {
+			b = b[:end]
+		}
+	}
+
+	offset := s.offset
+	s.offset += int64(len(b))
+	if len(b) == 0 || s.offset >= s.entry.Size {
+		s.eof = true
 	}
 
-	return strings.Join(contents, "\n")
+	return s.format(b, offset)
 }
 
-func (p *Preview) Highlight(entry *File) string {
-	// Determine lexer.
-	b, err := ioutil.ReadFile(entry.PathName)
+func (p *Preview) Highlight(ctx context.Context, entry *File) string {
+	b, err := readFile(entry)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+
+	return highlightCode(ctx, p.colorscheme, entry.Name, string(b))
+}
+
+// Markdown render markdown, code blocks are highlighted
+func (p *Preview) Markdown(ctx context.Context, entry *File, width int) string {
+	b, err := readFile(entry)
 	if err != nil {
 		log.Println(err)
 		return err.Error()
 	}
 
-	ext := filepath.Ext(entry.Name)
+	return renderMarkdown(ctx, string(b), width, func(lang, code string) string {
+		l := lexers.Get(lang)
+		if l == nil {
+			l = lexers.Analyse(code)
+		}
+		return formatCode(ctx, p.colorscheme, l, code)
+	})
+}
+
+// highlightCode highlight the text with the lexer detected by the file name,
+// the rest of the text is dropped when ctx is done
+func highlightCode(ctx context.Context, colorscheme, name, text string) string {
+	// Determine lexer.
+	ext := filepath.Ext(name)
 	l := lexers.Get(ext)
 	if l == nil {
-		l = lexers.Analyse(string(b))
+		l = lexers.Analyse(text)
 	}
+	return formatCode(ctx, colorscheme, l, text)
+}
+
+func formatCode(ctx context.Context, colorscheme string, l chroma.Lexer, text string) string {
 	if l == nil {
 		l = lexers.Fallback
 	}
@@ -103,16 +422,23 @@ func (p *Preview) Highlight(entry *File) string {
 	}
 
 	// Determine style.
-	s := styles.Get(p.colorscheme)
+	s := styles.Get(colorscheme)
 	if s == nil {
 		s = styles.Fallback
 	}
 
-	it, err := l.Tokenise(nil, string(b))
+	tokens, err := l.Tokenise(nil, text)
 	if err != nil {
 		log.Println(err)
 		return err.Error()
 	}
+	// tokens are made while formatting, so formatting stops at the next token
+	it := func() chroma.Token {
+		if ctx.Err() != nil {
+			return chroma.EOF
+		}
+		return tokens()
+	}
 
 	var buf bytes.Buffer
 
@@ -132,6 +458,13 @@ func (p *Preview) ScrollDown() {
 	p.TextView.ScrollToBeginning()
 	p.TextView.ScrollTo(orow, ocol)
 
+	if p.lineOffset >= maxOffset && p.stream != nil && !p.stream.eof {
+		// load more when reaching the end
+		p.TextView.Write([]byte(p.readChunk(p.stream)))
+		maxOffset, _ = p.TextView.ScrollToEnd().GetScrollOffset()
+		p.TextView.ScrollToBeginning()
+	}
+
 	if p.lineOffset > maxOffset {
 		return
 	}
@@ -0,0 +1,107 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderCanceled(t *testing.T) {
+	text := strings.Repeat("func main() {}\n", 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := plainText(highlightCode(ctx, "", "main.go", text)); got != "" {
+		t.Errorf("highlightCode() = %q after the cancel", got)
+	}
+	if got := plainText(highlightCode(context.Background(), "", "main.go", text)); got != text {
+		t.Errorf("highlightCode() = %q, want %q", got, text)
+	}
+	if got := renderMarkdown(ctx, "# title\n\ntext\n", 40, nil); got != "" {
+		t.Errorf("renderMarkdown() = %q after the cancel", got)
+	}
+}
+
+// readChunks read the file as the text stream until the end, and return each chunk
+func readChunks(t *testing.T, content string, chunk int64, headLines int) []string {
+	t.Helper()
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestContents(t, dir, map[string]string{"a.txt": content})
+	path := filepath.Join(dir, "a.txt")
+
+	p := &Preview{headLines: headLines}
+	s := &stream{
+		entry:  &File{Name: "a.txt", Path: dir, PathName: path, Size: int64(len(content))},
+		chunk:  chunk,
+		lines:  true,
+		format: func(b []byte, offset int64) string { return string(b) },
+	}
+	var chunks []string
+	for i := 0; !s.eof; i++ {
+		if i > len(content) {
+			t.Fatalf("the stream doesn't reach the end: %q", chunks)
+		}
+		chunks = append(chunks, p.readChunk(s))
+	}
+	return chunks
+}
+
+func TestReadChunk(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		chunk     int64
+		headLines int
+		want      []string
+	}{
+		{"cut at the last newline", "aaa\nbbb\nccc\n", 6, 100, []string{"aaa\n", "bbb\n", "ccc\n"}},
+		{"several lines", "a\nb\nccc\n", 5, 100, []string{"a\nb\n", "ccc\n"}},
+		{"last line without newline", "aaa\nbbb", 100, 100, []string{"aaa\nbbb"}},
+		// the chunk at the end of the file has no cut off line
+		{"last chunk without newline", "aaa\nbbb\nc", 6, 100, []string{"aaa\n", "bbb\nc"}},
+		// the line is split when it doesn't fit in a chunk
+		{"long line", "aaaaaaaaaa\nb\n", 4, 100, []string{"aaaa", "aaaa", "aa\n", "b\n"}},
+		{"head lines", "1\n2\n3\n4\n5", 100, 2, []string{"1\n2\n", "3\n4\n", "5"}},
+		{"empty", "", 4, 100, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readChunks(t, tt.content, tt.chunk, tt.headLines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollDownLoadsChunks(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	content := "1\n2\n3\n4\n5\n6\n7\n8\n"
+	writeTestContents(t, dir, map[string]string{"a.txt": content})
+	path := filepath.Join(dir, "a.txt")
+
+	p := NewPreview(PreviewConfig{}, false)
+	p.SetRect(0, 0, 20, 3)
+	p.stream = &stream{
+		entry:  &File{Name: "a.txt", Path: dir, PathName: path, Size: int64(len(content))},
+		chunk:  4,
+		lines:  true,
+		format: func(b []byte, offset int64) string { return string(b) },
+	}
+	p.SetText(p.readChunk(p.stream))
+
+	for i := 0; i < len(content) && !p.stream.eof; i++ {
+		p.ScrollDown()
+	}
+	if !p.stream.eof {
+		t.Fatal("scrolling doesn't load the end of the file")
+	}
+	if got := strings.TrimRight(p.GetText(false), "\n"); got != strings.TrimRight(content, "\n") {
+		t.Errorf("text = %q, want %q", got, content)
+	}
+}
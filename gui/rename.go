@@ -0,0 +1,251 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+// lines of the summary shown in the confirmation
+const maxRenameSummary = 15
+
+// renameOp rename old path to new path
+type renameOp struct {
+	oldPath string
+	newPath string
+}
+
+// renameTarget path to rename the file to, relative name is resolved from the directory of the file
+func renameTarget(f *File, name string) string {
+	name = strings.TrimSpace(name)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(f.Path, name)
+	}
+	return filepath.Clean(name)
+}
+
+// checkRenames check conflicts of renaming each file to the name,
+// errs[i] is nil if files[i] can be renamed
+func checkRenames(files []*File, names []string) []error {
+	sources := make(map[string]bool)
+	for _, f := range files {
+		sources[f.PathName] = true
+	}
+	targets := make(map[string]int)
+	for i, f := range files {
+		if strings.TrimSpace(names[i]) != "" {
+			targets[renameTarget(f, names[i])]++
+		}
+	}
+
+	errs := make([]error, len(files))
+	for i, f := range files {
+		if strings.TrimSpace(names[i]) == "" {
+			errs[i] = fmt.Errorf("%s: %s", ErrNoNewName, f.Name)
+			continue
+		}
+
+		newPath := renameTarget(f, names[i])
+		switch {
+		case targets[newPath] > 1:
+			errs[i] = fmt.Errorf("%s: %s", ErrRenameConflict, newPath)
+		case newPath == f.PathName:
+		// the target may be renamed away in the same operation
+		case system.IsExist(newPath) && !sources[newPath]:
+			errs[i] = fmt.Errorf("%s: %s", system.ErrFileExists, newPath)
+		case !system.IsExist(filepath.Dir(newPath)):
+			errs[i] = fmt.Errorf("%s: %s", ErrNotExistPath, filepath.Dir(newPath))
+		}
+	}
+	return errs
+}
+
+// planRenames make renames from the files and the new names,
+// and detect conflicts before anything is touched
+func planRenames(files []*File, names []string) ([]renameOp, error) {
+	if len(files) != len(names) {
+		return nil, ErrRenameLines
+	}
+
+	for _, err := range checkRenames(files, names) {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ops []renameOp
+	for i, f := range files {
+		if newPath := renameTarget(f, names[i]); newPath != f.PathName {
+			ops = append(ops, renameOp{oldPath: f.PathName, newPath: newPath})
+		}
+	}
+	return ops, nil
+}
+
+// applyRenames rename through temporary names to handle swaps and cycles.
+// if it fails on the way, the files are moved back to the original names,
+// and the files which can't be moved back are reported in the error
+func applyRenames(ops []renameOp) error {
+	temps := make([]string, len(ops))
+	for i, op := range ops {
+		temps[i] = filepath.Join(filepath.Dir(op.oldPath), fmt.Sprintf(".ff-rename-%d-%d", os.Getpid(), i))
+		if err := system.Rename(op.oldPath, temps[i]); err != nil {
+			return rollbackRenames(err, ops, temps, i, 0)
+		}
+	}
+
+	for i, op := range ops {
+		if err := system.Rename(temps[i], op.newPath); err != nil {
+			return rollbackRenames(err, ops, temps, len(ops), i)
+		}
+	}
+	return nil
+}
+
+// rollbackRenames move ops[:renamed] from the new paths back to the temporary names,
+// and then ops[:moved] from the temporary names to the original names.
+// the new paths are moved first because they may be the original names of other files
+func rollbackRenames(err error, ops []renameOp, temps []string, moved, renamed int) error {
+	var stranded []string
+	failed := make(map[int]bool)
+	for j := renamed - 1; j >= 0; j-- {
+		if err := system.Rename(ops[j].newPath, temps[j]); err != nil {
+			log.Println(err)
+			stranded = append(stranded, fmt.Sprintf("%s (was %s)", ops[j].newPath, ops[j].oldPath))
+			failed[j] = true
+		}
+	}
+	for j := moved - 1; j >= 0; j-- {
+		if failed[j] {
+			continue
+		}
+		if err := system.Rename(temps[j], ops[j].oldPath); err != nil {
+			log.Println(err)
+			stranded = append(stranded, fmt.Sprintf("%s (was %s)", temps[j], ops[j].oldPath))
+		}
+	}
+
+	if len(stranded) > 0 {
+		return fmt.Errorf("%s\n%s: %s", err, ErrRenameRestore, strings.Join(stranded, ", "))
+	}
+	return err
+}
+
+func renameSummary(ops []renameOp) string {
+	var lines []string
+	for i, op := range ops {
+		if i == maxRenameSummary {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(ops)-i))
+			break
+		}
+		lines = append(lines, filepath.Base(op.oldPath)+" -> "+filepath.Base(op.newPath))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// confirmRenames show the summary of renames and apply them if confirmed
+func (gui *Gui) confirmRenames(ops []renameOp) {
+	if len(ops) == 0 {
+		gui.Message("nothing to rename", FileTablePanel)
+		return
+	}
+
+	message := fmt.Sprintf("do you want to rename %d files?\n\n%s", len(ops), renameSummary(ops))
+	gui.Confirm(message, "rename", FileTablePanel, func() error {
+		defer gui.FileBrowser.UpdateView()
+		if err := applyRenames(ops); err != nil {
+			return err
+		}
+		marks.Clear()
+		return nil
+	})
+}
+
+// renameFiles marked files, or all files in the current directory
+func (gui *Gui) renameFiles() ([]*File, error) {
+	files := marks.Files()
+	if len(files) == 0 {
+		current := gui.InputPath.GetText()
+		files = GetFiles(current, "", gui.Config.IgnoreCase, gui.Config.ShowHidden, gui.Config.Ignore.Enable)
+	}
+
+	for _, f := range files {
+		if s3.IsPath(f.PathName) {
+			return nil, ErrNotSupported
+		}
+	}
+	return files, nil
+}
+
+// BulkRename edit names of the marked files, or all files in the current directory with $EDITOR
+func (gui *Gui) BulkRename() error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return ErrNoEditor
+	}
+
+	files, err := gui.renameFiles()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	// files in other directories are written with full path
+	dir := files[0].Path
+	var lines []string
+	for _, f := range files {
+		if f.Path != dir {
+			dir = ""
+		}
+	}
+	for _, f := range files {
+		if dir == "" {
+			lines = append(lines, f.PathName)
+		} else {
+			lines = append(lines, f.Name)
+		}
+	}
+
+	tmp, err := ioutil.TempFile("", "ff-rename-*.txt")
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(strings.Join(lines, "\n") + "\n")
+	tmp.Close()
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	var editErr error
+	gui.App.Suspend(func() {
+		editErr = gui.ExecCmd(true, editor, tmp.Name())
+	})
+	if editErr != nil {
+		log.Printf("%s: %s\n", ErrEdit, editErr)
+		return editErr
+	}
+
+	b, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	names := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	ops, err := planRenames(files, names)
+	if err != nil {
+		return err
+	}
+
+	gui.confirmRenames(ops)
+	return nil
+}
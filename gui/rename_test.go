@@ -0,0 +1,184 @@
+package gui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestContents create files with the contents, parent directories are created
+func writeTestContents(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeTestFiles create files whose content is their name
+func writeTestFiles(t *testing.T, dir string, names ...string) []*File {
+	t.Helper()
+	var files []*File
+	for _, name := range names {
+		writeTestContents(t, dir, map[string]string{name: name})
+		path := filepath.Join(dir, name)
+		files = append(files, &File{Name: filepath.Base(path), Path: filepath.Dir(path), PathName: path})
+	}
+	return files
+}
+
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// assertContents check each file has the content
+func assertContents(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	for name, content := range want {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if string(b) != content {
+			t.Errorf("%s: content = %q, want %q", name, b, content)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if _, ok := want[e.Name()]; !ok && !e.IsDir() {
+			t.Errorf("unexpected file %s", e.Name())
+		}
+	}
+}
+
+func TestPlanRenames(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	files := writeTestFiles(t, dir, "a", "b", "c")
+
+	tests := []struct {
+		name    string
+		names   []string
+		wantErr error
+		wantOps int
+	}{
+		{"unchanged", []string{"a", "b", "c"}, nil, 0},
+		{"rename one", []string{"a", "b", "d"}, nil, 1},
+		{"swap", []string{"b", "a", "c"}, nil, 2},
+		{"cycle", []string{"b", "c", "a"}, nil, 3},
+		{"lines changed", []string{"a", "b"}, ErrRenameLines, 0},
+		{"empty name", []string{"a", "", "c"}, ErrNoNewName, 0},
+		{"same target", []string{"d", "d", "c"}, ErrRenameConflict, 0},
+		{"existing file", []string{"a", "b", "b"}, ErrRenameConflict, 0},
+		{"missing dir", []string{"a", "b", "x/c"}, ErrNotExistPath, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := planRenames(files, tt.names)
+			if tt.wantErr != nil {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr.Error()) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ops) != tt.wantOps {
+				t.Errorf("len(ops) = %d, want %d", len(ops), tt.wantOps)
+			}
+		})
+	}
+}
+
+func TestPlanRenamesExisting(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	files := writeTestFiles(t, dir, "a", "b")
+	writeTestFiles(t, dir, "other")
+
+	if _, err := planRenames(files[:1], []string{"other"}); err == nil {
+		t.Error("renaming to the existing file should fail")
+	}
+	// the target is renamed away in the same operation
+	if _, err := planRenames(files, []string{"b", "c"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApplyRenames(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  map[string]string
+	}{
+		{"swap", []string{"b", "a", "c"}, map[string]string{"a": "b", "b": "a", "c": "c"}},
+		{"cycle", []string{"b", "c", "a"}, map[string]string{"a": "c", "b": "a", "c": "b"}},
+		{"new names", []string{"x", "y", "z"}, map[string]string{"x": "a", "y": "b", "z": "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testDir(t)
+			defer os.RemoveAll(dir)
+			files := writeTestFiles(t, dir, "a", "b", "c")
+
+			ops, err := planRenames(files, tt.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := applyRenames(ops); err != nil {
+				t.Fatal(err)
+			}
+			assertContents(t, dir, tt.want)
+		})
+	}
+}
+
+func TestApplyRenamesRollback(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "a", "b", "c")
+
+	join := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	// the swap is done before the last rename fails
+	ops := []renameOp{
+		{oldPath: join("a"), newPath: join("b")},
+		{oldPath: join("b"), newPath: join("a")},
+		{oldPath: join("c"), newPath: join("missing/c")},
+	}
+	if err := applyRenames(ops); err == nil {
+		t.Fatal("rename to the missing directory should fail")
+	}
+	assertContents(t, dir, map[string]string{"a": "a", "b": "b", "c": "c"})
+
+	// the first rename fails
+	ops = []renameOp{
+		{oldPath: join("a"), newPath: join("x")},
+		{oldPath: join("missing"), newPath: join("y")},
+	}
+	if err := applyRenames(ops); err == nil {
+		t.Fatal("rename of the missing file should fail")
+	}
+	assertContents(t, dir, map[string]string{"a": "a", "b": "b", "c": "c"})
+}
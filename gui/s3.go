@@ -0,0 +1,165 @@
+package gui
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+)
+
+var s3Client *s3.Client
+
+// GetS3Files get objects and prefixes in s3 path
+func GetS3Files(dir, searchWord string, ignorecase, showHidden, hideIgnored bool) []*File {
+	objects, err := s3Client.List(dir)
+	if err != nil {
+		log.Printf("%s: %s\n", ErrReadDir, err)
+		return nil
+	}
+
+	var names []string
+	for _, o := range objects {
+		names = append(names, path.Base(o.Key))
+	}
+	var ignored map[string]bool
+	if hideIgnored {
+		ignored = ignores.Ignored(context.Background(), dir, names)
+	}
+
+	var files []*File
+	for _, o := range objects {
+		name := path.Base(o.Key)
+		if !showHidden && name[0] == '.' {
+			continue
+		}
+		if ignored[name] {
+			continue
+		}
+		if !matchName(name, searchWord, ignorecase) {
+			continue
+		}
+
+		var change string
+		if !o.LastModified.IsZero() {
+			change = o.LastModified.Local().Format(dateFmt)
+		}
+
+		files = append(files, &File{
+			Name:     name,
+			Change:   change,
+			Size:     o.Size,
+			IsDir:    o.IsPrefix,
+			PathName: s3.Join(dir, name),
+			Path:     dir,
+			Viewable: true,
+		})
+	}
+
+	return files
+}
+
+// parentPath return parent path of local or s3 path
+func parentPath(path string) string {
+	if s3.IsPath(path) {
+		return s3.Parent(path)
+	}
+	return filepath.Dir(path)
+}
+
+// joinPath join local or s3 dir path and name
+func joinPath(dir, name string) string {
+	if s3.IsPath(dir) {
+		return s3.Join(dir, name)
+	}
+	return filepath.Join(dir, name)
+}
+
+// copyEntry copy entry to target.
+// if one of them is s3 path, upload or download it.
+func copyEntry(entry *File, target string) error {
+	switch {
+	case s3.IsPath(entry.PathName) && s3.IsPath(target):
+		return s3Client.Copy(entry.PathName, target, entry.IsDir)
+	case s3.IsPath(entry.PathName):
+		if system.IsExist(target) {
+			return system.ErrFileExists
+		}
+		return s3Client.Download(entry.PathName, target, entry.IsDir)
+	case s3.IsPath(target):
+		return s3Client.Upload(entry.PathName, target)
+	}
+	return system.Copy(entry.PathName, target)
+}
+
+// removeEntry remove file or directory
+func removeEntry(entry *File) error {
+	if s3.IsPath(entry.PathName) {
+		return s3Client.Remove(entry.PathName, entry.IsDir)
+	}
+
+	if entry.IsDir {
+		return system.RemoveDirAll(entry.PathName)
+	}
+	return system.RemoveFile(entry.PathName)
+}
+
+// newFile create new file or s3 object
+func newFile(target string, perm os.FileMode) error {
+	if s3.IsPath(target) {
+		return s3Client.Write(target, nil, 0)
+	}
+	return system.NewFile(target, perm)
+}
+
+// newDir create new directory or s3 prefix
+func newDir(target string, perm os.FileMode) error {
+	if s3.IsPath(target) {
+		return s3Client.MakeDir(target)
+	}
+	return system.NewDir(target, perm)
+}
+
+// rename rename file or directory, s3 objects can't be renamed
+func rename(oldpath, newpath string) error {
+	if s3.IsPath(oldpath) || s3.IsPath(newpath) {
+		return ErrNotSupported
+	}
+	return system.Rename(oldpath, newpath)
+}
+
+// readFile read file or s3 object
+func readFile(entry *File) ([]byte, error) {
+	if s3.IsPath(entry.PathName) {
+		return s3Client.Read(entry.PathName, 0, 0)
+	}
+	return ioutil.ReadFile(entry.PathName)
+}
+
+// readFileAt read length bytes from offset of file or s3 object
+func readFileAt(entry *File, offset, length int64) ([]byte, error) {
+	if s3.IsPath(entry.PathName) {
+		if offset >= entry.Size {
+			return nil, nil
+		}
+		return s3Client.Read(entry.PathName, offset, length)
+	}
+
+	f, err := os.Open(entry.PathName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b := make([]byte, length)
+	n, err := f.ReadAt(b, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return b[:n], nil
+}
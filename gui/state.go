@@ -0,0 +1,167 @@
+package gui
+
+import (
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/system"
+	"gopkg.in/yaml.v2"
+)
+
+// State view options which can be changed at runtime,
+// remembered across sessions if remember_state is true
+type State struct {
+	ShowHidden  bool `yaml:"show_hidden"`
+	Preview     bool `yaml:"preview"`
+	Tree        bool `yaml:"tree"`
+	HideIgnored bool `yaml:"hide_ignored"`
+}
+
+func stateFile(config Config) string {
+	return filepath.Join(config.ConfigDir, "state.yaml")
+}
+
+// LoadState override the config with the remembered state
+func LoadState(config *Config) error {
+	if !config.RememberState || config.ConfigDir == "" {
+		return nil
+	}
+
+	file := stateFile(*config)
+	if !system.IsExist(file) {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var state State
+	if err := yaml.Unmarshal(b, &state); err != nil {
+		return err
+	}
+
+	config.ShowHidden = state.ShowHidden
+	config.Preview.Enable = state.Preview
+	config.EnableTree = state.Tree
+	config.Ignore.Enable = state.HideIgnored
+	return nil
+}
+
+func (gui *Gui) saveState() {
+	if !gui.Config.RememberState || gui.Config.ConfigDir == "" {
+		return
+	}
+
+	state := State{
+		ShowHidden:  gui.Config.ShowHidden,
+		Preview:     gui.Config.Preview.Enable,
+		Tree:        gui.Config.EnableTree,
+		HideIgnored: gui.Config.Ignore.Enable,
+	}
+
+	b, err := yaml.Marshal(state)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if err := ioutil.WriteFile(stateFile(gui.Config), b, 0666); err != nil {
+		log.Println(err)
+	}
+}
+
+func (gui *Gui) newFileBrowser() FileBrowser {
+	var browser FileBrowser
+	if gui.Config.EnableTree {
+		browser = NewTree(gui.Config.IgnoreCase, gui.Config.ShowHidden)
+	} else {
+		browser = NewFileTable(gui.Config.IgnoreCase, gui.Config.ShowHidden)
+	}
+	browser.SetHideIgnored(gui.Config.Ignore.Enable)
+	return browser
+}
+
+// layout put the path bar, the file browser and the preview on the main page
+func (gui *Gui) layout() {
+	pathBar := tview.NewFlex().
+		AddItem(gui.InputPath, 0, 3, true).
+		AddItem(gui.GitBranch, 0, 1, false)
+
+	gui.grid.Clear().SetRows(1, 0).
+		AddItem(pathBar, 0, 0, 1, 2, 0, 0, true)
+
+	if gui.Config.Preview.Enable {
+		gui.grid.SetColumns(0, 0).
+			AddItem(gui.FileBrowser, 1, 0, 1, 1, 0, 0, true).
+			AddItem(gui.Preview, 1, 1, 1, 1, 0, 0, true)
+
+		// update after the drawing to render with the panel size
+		go gui.App.QueueUpdateDraw(func() {
+			gui.Preview.UpdateView(gui, gui.FileBrowser.GetSelectEntry())
+		})
+	} else {
+		gui.grid.SetColumns(0).
+			AddItem(gui.FileBrowser, 1, 0, 1, 2, 0, 0, true)
+	}
+}
+
+// ToggleHidden toggle showing hidden files
+func (gui *Gui) ToggleHidden() {
+	gui.Config.ShowHidden = !gui.Config.ShowHidden
+	gui.FileBrowser.SetShowHidden(gui.Config.ShowHidden)
+	gui.Preview.SetShowHidden(gui.Config.ShowHidden)
+
+	gui.FileBrowser.UpdateView()
+	if gui.Config.Preview.Enable {
+		gui.Preview.UpdateView(gui, gui.FileBrowser.GetSelectEntry())
+	}
+	gui.saveState()
+}
+
+// ToggleIgnored toggle hiding ignored files
+func (gui *Gui) ToggleIgnored() {
+	gui.Config.Ignore.Enable = !gui.Config.Ignore.Enable
+	gui.FileBrowser.SetHideIgnored(gui.Config.Ignore.Enable)
+	gui.Preview.SetHideIgnored(gui.Config.Ignore.Enable)
+
+	gui.FileBrowser.UpdateView()
+	if gui.Config.Preview.Enable {
+		gui.Preview.UpdateView(gui, gui.FileBrowser.GetSelectEntry())
+	}
+	gui.saveState()
+}
+
+// TogglePreview show or hide the preview panel
+func (gui *Gui) TogglePreview() {
+	gui.Config.Preview.Enable = !gui.Config.Preview.Enable
+	gui.layout()
+	gui.saveState()
+}
+
+// ToggleTree switch the file browser between table and tree,
+// keeping the current directory and the selected entry
+func (gui *Gui) ToggleTree() {
+	current := gui.InputPath.GetText()
+	entry := gui.FileBrowser.GetSelectEntry()
+
+	gui.Config.EnableTree = !gui.Config.EnableTree
+	gui.FileBrowser = gui.newFileBrowser()
+	gui.FileBrowser.Keybinding(gui)
+
+	// the search field belongs to the previous file browser
+	gui.Pages.RemovePage("search")
+
+	if err := gui.FileBrowser.ChangeDir(gui, current, current); err != nil {
+		log.Println(err)
+	}
+	if entry != nil {
+		gui.FileBrowser.SelectEntry(entry.PathName)
+	}
+
+	gui.layout()
+	gui.FocusPanel(FileTablePanel)
+	gui.saveState()
+}
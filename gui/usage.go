@@ -0,0 +1,352 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+)
+
+// width of the percentage bar
+const usageBarWidth = 20
+
+// usageNode file or directory with the cumulative size
+type usageNode struct {
+	name     string
+	path     string
+	isDir    bool
+	size     int64
+	files    int64
+	parent   *usageNode
+	children []*usageNode
+}
+
+// usageProgress files and bytes which have been scanned
+type usageProgress struct {
+	files int64
+	bytes int64
+	// hard linked files which have been counted
+	inodes map[string]bool
+}
+
+// diskUsage allocated size of the file, sparse files use less than the size
+func diskUsage(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Blocks * 512
+	}
+	return info.Size()
+}
+
+// countedLink check the hard linked file has been counted, and remember it
+func (p *usageProgress) countedLink(path string, info os.FileInfo) bool {
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Nlink < 2 {
+		return false
+	}
+	if p.inodes == nil {
+		p.inodes = make(map[string]bool)
+	}
+	inode := fileInode(path, info)
+	if p.inodes[inode] {
+		return true
+	}
+	p.inodes[inode] = true
+	return false
+}
+
+// scanUsage scan the directory tree, symlinks are not followed and hard links are counted once
+func scanUsage(ctx context.Context, path string, info os.FileInfo, parent *usageNode, progress *usageProgress) (*usageNode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	node := &usageNode{
+		name:   info.Name(),
+		path:   path,
+		isDir:  info.IsDir(),
+		parent: parent,
+	}
+	if !progress.countedLink(path, info) {
+		node.size = diskUsage(info)
+	}
+	atomic.AddInt64(&progress.bytes, node.size)
+	if !node.isDir {
+		node.files = 1
+		atomic.AddInt64(&progress.files, 1)
+		return node, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		// unreadable directories are counted as empty
+		log.Printf("%s: %s\n", ErrReadDir, err)
+		return node, nil
+	}
+
+	for _, entry := range entries {
+		child, err := scanUsage(ctx, filepath.Join(path, entry.Name()), entry, node, progress)
+		if err != nil {
+			return nil, err
+		}
+		node.size += child.size
+		node.files += child.files
+		node.children = append(node.children, child)
+	}
+
+	sort.SliceStable(node.children, func(i, j int) bool {
+		return node.children[i].size > node.children[j].size
+	})
+	return node, nil
+}
+
+// remove remove the node from the tree and subtract its size from the parents
+func (n *usageNode) remove() {
+	parent := n.parent
+	if parent == nil {
+		return
+	}
+	for i, child := range parent.children {
+		if child == n {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			break
+		}
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.size -= n.size
+		p.files -= n.files
+	}
+}
+
+func usageBar(size, total int64) string {
+	var width int
+	if total > 0 {
+		width = int((size*usageBarWidth + total/2) / total)
+	}
+	return "[" + strings.Repeat("#", width) + strings.Repeat(" ", usageBarWidth-width) + "]"
+}
+
+// Usage disk usage of the directory tree like ncdu
+type Usage struct {
+	*tview.Table
+	root    *usageNode
+	current *usageNode
+	cancel  context.CancelFunc
+}
+
+func NewUsage() *Usage {
+	u := &Usage{
+		Table: tview.NewTable().Select(0, 0).SetFixed(1, 1).SetSelectable(true, false),
+	}
+	u.SetBorder(true).SetTitleAlign(tview.AlignLeft)
+	return u
+}
+
+// Scan scan the directory in background with the progress, and show the usage when it's done
+func (u *Usage) Scan(gui *Gui, dir string) error {
+	if s3.IsPath(dir) {
+		return ErrNotSupported
+	}
+	// the directory may be a symlink which was followed
+	info, err := os.Stat(dir)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if u.cancel != nil {
+		u.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancel = cancel
+
+	pageName := "usage_scan"
+	progress := &usageProgress{}
+	stop := gui.Progress(pageName, FileTablePanel, cancel, func() string {
+		return fmt.Sprintf("scanning %s\n\n%d files, %s",
+			dir, atomic.LoadInt64(&progress.files), humanize.Bytes(uint64(atomic.LoadInt64(&progress.bytes))))
+	})
+
+	go func() {
+		root, err := scanUsage(ctx, dir, info, nil, progress)
+		stop()
+		if err != nil {
+			return
+		}
+
+		gui.App.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			gui.Pages.RemovePage(pageName)
+			u.Show(gui, root)
+		})
+	}()
+	return nil
+}
+
+// Show show the usage of the scanned tree
+func (u *Usage) Show(gui *Gui, root *usageNode) {
+	u.root = root
+	u.current = root
+	u.UpdateView()
+	u.Select(1, 0)
+
+	gui.Pages.AddAndSwitchToPage("usage", u, true)
+	gui.FocusPanel(UsagePanel)
+}
+
+func (u *Usage) Close(gui *Gui) {
+	u.root = nil
+	u.current = nil
+	gui.Pages.RemovePage("usage").SwitchToPage("main")
+	gui.FocusPanel(FileTablePanel)
+	gui.FileBrowser.UpdateView()
+}
+
+func (u *Usage) UpdateView() {
+	table := u.Clear()
+	u.SetTitle(fmt.Sprintf("usage: %s %s (%d files)", u.current.path, humanize.Bytes(uint64(u.current.size)), u.current.files))
+
+	for i, h := range []string{"Name", "Size", "Percent", "", "Files"} {
+		table.SetCell(0, i, &tview.TableCell{
+			Text:            h,
+			NotSelectable:   true,
+			Align:           tview.AlignLeft,
+			Color:           tcell.ColorYellow,
+			BackgroundColor: tcell.ColorDefault,
+		})
+	}
+
+	for i, n := range u.current.children {
+		color := tcell.ColorWhite
+		name := n.name
+		if n.isDir {
+			color = tcell.ColorDarkCyan
+			name += "/"
+		}
+
+		var percent float64
+		if u.current.size > 0 {
+			percent = float64(n.size) * 100 / float64(u.current.size)
+		}
+
+		table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(name)).SetTextColor(color))
+		table.SetCell(i+1, 1, tview.NewTableCell(humanize.Bytes(uint64(n.size))).SetTextColor(color))
+		table.SetCell(i+1, 2, tview.NewTableCell(fmt.Sprintf("%5.1f%%", percent)).SetTextColor(color))
+		table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(usageBar(n.size, u.current.size))).SetTextColor(color))
+		table.SetCell(i+1, 4, tview.NewTableCell(fmt.Sprint(n.files)).SetTextColor(color))
+	}
+}
+
+// GetSelectNode get the selected entry
+func (u *Usage) GetSelectNode() *usageNode {
+	row, _ := u.GetSelection()
+	if u.current == nil || row < 1 || row > len(u.current.children) {
+		return nil
+	}
+	return u.current.children[row-1]
+}
+
+// Enter show the usage of the selected directory
+func (u *Usage) Enter() {
+	node := u.GetSelectNode()
+	if node == nil || !node.isDir {
+		return
+	}
+	u.current = node
+	u.UpdateView()
+	u.Select(1, 0)
+}
+
+// Leave show the usage of the parent directory, and select the directory which was shown
+func (u *Usage) Leave() {
+	if u.current == nil || u.current.parent == nil {
+		return
+	}
+	prev := u.current
+	u.current = u.current.parent
+	u.UpdateView()
+	for i, n := range u.current.children {
+		if n == prev {
+			u.Select(i+1, 0)
+			return
+		}
+	}
+}
+
+// Remove remove the selected entry after the confirmation like the file browser
+func (u *Usage) Remove(gui *Gui) {
+	node := u.GetSelectNode()
+	if node == nil {
+		return
+	}
+
+	gui.Confirm("do you want to remove this?", "yes", UsagePanel, func() error {
+		entry := &File{
+			Name:     filepath.Base(node.path),
+			Path:     filepath.Dir(node.path),
+			PathName: node.path,
+			IsDir:    node.isDir,
+		}
+		if err := removeEntry(entry); err != nil {
+			log.Println(err)
+			return err
+		}
+
+		row, _ := u.GetSelection()
+		node.remove()
+		u.UpdateView()
+		if row >= u.GetRowCount() {
+			row = u.GetRowCount() - 1
+		}
+		u.Select(row, 0)
+		return nil
+	})
+}
+
+func (u *Usage) Keybinding(gui *Gui) {
+	u.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyF1:
+			gui.Help.UpdateView(UsagePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("usage")
+			return nil
+		case tcell.KeyEscape:
+			u.Close(gui)
+			return nil
+		case tcell.KeyEnter:
+			u.Enter()
+			return nil
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			u.Leave()
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'q':
+			u.Close(gui)
+		case '?':
+			gui.Help.UpdateView(UsagePanel)
+			gui.Pages.AddAndSwitchToPage("help", gui.Modal(gui.Help, 0, 0), true).ShowPage("usage")
+		case 'l':
+			u.Enter()
+		case 'h':
+			u.Leave()
+		case 'd':
+			u.Remove(gui)
+		default:
+			return event
+		}
+		return nil
+	})
+}
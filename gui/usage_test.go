@@ -0,0 +1,157 @@
+package gui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func scanTestUsage(t *testing.T, ctx context.Context, dir string) (*usageNode, error) {
+	t.Helper()
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scanUsage(ctx, dir, info, nil, &usageProgress{})
+}
+
+func usageChild(n *usageNode, name string) *usageNode {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// lstatUsage disk usage of the path
+func lstatUsage(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diskUsage(info)
+}
+
+func TestScanUsage(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "a", "sub/b", "sub/c")
+	writeTestContents(t, dir, map[string]string{"large": strings.Repeat("x", 64*1024)})
+	if err := os.Link(filepath.Join(dir, "large"), filepath.Join(dir, "sub", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "large"), filepath.Join(dir, "symlink")); err != nil {
+		t.Fatal(err)
+	}
+	sparse, err := os.Create(filepath.Join(dir, "sparse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sparse.Truncate(64 << 20); err != nil {
+		t.Fatal(err)
+	}
+	sparse.Close()
+
+	root, err := scanTestUsage(t, context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.files != 7 {
+		t.Errorf("files = %d, want 7", root.files)
+	}
+
+	// the hard link is counted once
+	large, link := usageChild(root, "large"), usageChild(usageChild(root, "sub"), "link")
+	if large.size+link.size != lstatUsage(t, filepath.Join(dir, "large")) {
+		t.Errorf("sizes of hard links = %d and %d, want one of them is 0", large.size, link.size)
+	}
+	// the allocated size instead of the size
+	if got := usageChild(root, "sparse").size; got >= 64<<20 {
+		t.Errorf("size of the sparse file = %d, want less than the size", got)
+	}
+	// the symlink is not followed
+	if got := usageChild(root, "symlink").size; got >= large.size+link.size {
+		t.Errorf("size of the symlink = %d, want the size of the link", got)
+	}
+
+	// directories have their own size and the sizes of the children
+	var sum int64
+	for _, child := range root.children {
+		sum += child.size
+	}
+	if want := sum + lstatUsage(t, dir); root.size != want {
+		t.Errorf("size = %d, want %d", root.size, want)
+	}
+	for i := 1; i < len(root.children); i++ {
+		if root.children[i-1].size < root.children[i].size {
+			t.Errorf("children aren't sorted by size")
+		}
+	}
+}
+
+func TestScanUsageSymlinkRoot(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "real/a", "real/b")
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(filepath.Join(dir, "real"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := scanTestUsage(t, context.Background(), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.isDir || root.files != 2 {
+		t.Errorf("isDir = %v, files = %d, want the scan of the linked directory", root.isDir, root.files)
+	}
+}
+
+func TestScanUsageCancel(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, "a", "sub/b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scanTestUsage(t, ctx, dir); err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestUsageNodeRemove(t *testing.T) {
+	root := &usageNode{name: "root", isDir: true, size: 100, files: 4}
+	sub := &usageNode{name: "sub", isDir: true, size: 70, files: 3, parent: root}
+	a := &usageNode{name: "a", size: 30, files: 1, parent: root}
+	b := &usageNode{name: "b", size: 40, files: 1, parent: sub}
+	c := &usageNode{name: "c", size: 20, files: 1, parent: sub}
+	d := &usageNode{name: "d", size: 10, files: 1, parent: sub}
+	root.children = []*usageNode{sub, a}
+	sub.children = []*usageNode{b, c, d}
+
+	c.remove()
+	if len(sub.children) != 2 || usageChild(sub, "c") != nil {
+		t.Errorf("c is not removed from the children")
+	}
+	if sub.size != 50 || sub.files != 2 {
+		t.Errorf("sub = %d bytes, %d files, want 50 bytes, 2 files", sub.size, sub.files)
+	}
+	if root.size != 80 || root.files != 3 {
+		t.Errorf("root = %d bytes, %d files, want 80 bytes, 3 files", root.size, root.files)
+	}
+
+	sub.remove()
+	if len(root.children) != 1 || root.size != 30 || root.files != 1 {
+		t.Errorf("root = %d children, %d bytes, %d files, want 1, 30, 1", len(root.children), root.size, root.files)
+	}
+
+	// the root has no parent
+	root.remove()
+	if root.size != 30 {
+		t.Errorf("size of the root = %d, want 30", root.size)
+	}
+}
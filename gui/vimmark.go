@@ -0,0 +1,207 @@
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"unicode"
+
+	"github.com/rivo/tview"
+	"github.com/skanehira/ff/s3"
+	"github.com/skanehira/ff/system"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	setMarkKey  = 'm'
+	jumpMarkKey = '\''
+)
+
+// DirMark is a position which is marked with a letter,
+// uppercase marks are saved to the disk
+type DirMark struct {
+	Dir   string `yaml:"dir"`
+	Entry string `yaml:"entry,omitempty"`
+}
+
+func (m DirMark) Exists() bool {
+	return s3.IsPath(m.Dir) || system.IsExist(m.Dir)
+}
+
+type DirMarks struct {
+	marks map[rune]DirMark
+	file  string
+}
+
+func isMarkLetter(r rune) bool {
+	return r <= unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+func dirMarksFile(config Config) string {
+	if config.ConfigDir == "" {
+		return ""
+	}
+	return filepath.Join(config.ConfigDir, "marks.yaml")
+}
+
+// NewDirMarks create marks with the uppercase marks saved in the config dir
+func NewDirMarks(config Config) *DirMarks {
+	m := &DirMarks{
+		marks: map[rune]DirMark{},
+		file:  dirMarksFile(config),
+	}
+	if err := m.load(); err != nil {
+		log.Println(err)
+	}
+	return m
+}
+
+func (m *DirMarks) load() error {
+	if m.file == "" || !system.IsExist(m.file) {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(m.file)
+	if err != nil {
+		return err
+	}
+
+	var saved map[string]DirMark
+	if err := yaml.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+
+	for key, mark := range saved {
+		r := []rune(key)
+		if len(r) == 1 && unicode.IsUpper(r[0]) && isMarkLetter(r[0]) {
+			m.marks[r[0]] = mark
+		}
+	}
+	return nil
+}
+
+func (m *DirMarks) save() error {
+	if m.file == "" {
+		return nil
+	}
+
+	saved := map[string]DirMark{}
+	for r, mark := range m.marks {
+		if unicode.IsUpper(r) {
+			saved[string(r)] = mark
+		}
+	}
+
+	b, err := yaml.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.file, b, 0666)
+}
+
+// Set mark the position, uppercase marks are saved immediately
+func (m *DirMarks) Set(r rune, mark DirMark) error {
+	if !isMarkLetter(r) {
+		return fmt.Errorf("%s: %c", ErrInvalidMark, r)
+	}
+	m.marks[r] = mark
+	if unicode.IsUpper(r) {
+		return m.save()
+	}
+	return nil
+}
+
+func (m *DirMarks) Get(r rune) (DirMark, error) {
+	mark, ok := m.marks[r]
+	if !ok {
+		return mark, fmt.Errorf("%s: %c", ErrNoMark, r)
+	}
+	return mark, nil
+}
+
+// Letters return the marked letters, lowercase first
+func (m *DirMarks) Letters() []rune {
+	letters := make([]rune, 0, len(m.marks))
+	for r := range m.marks {
+		letters = append(letters, r)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		li, lj := unicode.IsLower(letters[i]), unicode.IsLower(letters[j])
+		if li != lj {
+			return li
+		}
+		return letters[i] < letters[j]
+	})
+	return letters
+}
+
+// SetMark mark the current directory and the selected entry
+func (gui *Gui) SetMark(r rune) error {
+	mark := DirMark{Dir: gui.InputPath.GetText()}
+	if entry := gui.FileBrowser.GetSelectEntry(); entry != nil {
+		mark.Entry = entry.PathName
+	}
+	return gui.DirMarks.Set(r, mark)
+}
+
+// JumpMark go to the marked directory and select the marked entry
+func (gui *Gui) JumpMark(r rune) error {
+	mark, err := gui.DirMarks.Get(r)
+	if err != nil {
+		return err
+	}
+	if !mark.Exists() {
+		return fmt.Errorf("%s: %s", ErrNotExistPath, mark.Dir)
+	}
+
+	if err := gui.FileBrowser.ChangeDir(gui, gui.InputPath.GetText(), mark.Dir); err != nil {
+		return err
+	}
+	if mark.Entry != "" {
+		gui.FileBrowser.SelectEntry(mark.Entry)
+	}
+	return nil
+}
+
+// MarkList show all marks, press the letter to jump to the mark
+func (gui *Gui) MarkList() {
+	letters := gui.DirMarks.Letters()
+	if len(letters) == 0 {
+		gui.Message(ErrNoMarks.Error(), FileTablePanel)
+		return
+	}
+
+	pageName := "marks"
+	closeList := func() {
+		gui.Pages.RemovePage(pageName)
+		gui.FocusPanel(FileTablePanel)
+	}
+	jump := func(r rune) func() {
+		return func() {
+			closeList()
+			if err := gui.JumpMark(r); err != nil {
+				log.Println(err)
+				gui.Message(err.Error(), FileTablePanel)
+			}
+		}
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	for _, r := range letters {
+		mark, _ := gui.DirMarks.Get(r)
+		text := tview.Escape(shortPath(mark.Dir))
+		if !mark.Exists() {
+			text = "[red]" + text + " (missing)[-]"
+		} else if mark.Entry != "" {
+			text += " [gray]" + tview.Escape(filepath.Base(mark.Entry)) + "[-]"
+		}
+		list.AddItem(text, "", r, jump(r))
+	}
+
+	list.SetBorder(true).SetTitle("marks").SetTitleAlign(tview.AlignLeft)
+	list.SetDoneFunc(closeList)
+
+	gui.Pages.AddAndSwitchToPage(pageName, gui.Modal(list, 80, list.GetItemCount()+2), true).ShowPage("main")
+}
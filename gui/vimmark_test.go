@@ -0,0 +1,82 @@
+package gui
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirMarksSave(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	config := Config{ConfigDir: dir}
+
+	m := NewDirMarks(config)
+	marks := map[rune]DirMark{
+		'A': {Dir: "/tmp", Entry: "/tmp/a"},
+		'B': {Dir: "/usr"},
+		'a': {Dir: "/home"},
+	}
+	for r, mark := range marks {
+		if err := m.Set(r, mark); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only uppercase marks are saved
+	loaded := NewDirMarks(config)
+	if got, want := loaded.Letters(), []rune{'A', 'B'}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("loaded letters = %q, want %q", got, want)
+	}
+	for _, r := range []rune{'A', 'B'} {
+		if got, err := loaded.Get(r); err != nil || got != marks[r] {
+			t.Errorf("Get(%c) = %+v, %v, want %+v", r, got, err, marks[r])
+		}
+	}
+	if _, err := loaded.Get('a'); err == nil {
+		t.Errorf("lowercase mark is loaded")
+	}
+}
+
+func TestDirMarksLoad(t *testing.T) {
+	dir := testDir(t)
+	defer os.RemoveAll(dir)
+	content := "A:\n  dir: /tmp\n  entry: /tmp/a\n" +
+		// invalid keys are ignored
+		"b:\n  dir: /lower\n" +
+		"AB:\n  dir: /long\n" +
+		"1:\n  dir: /digit\n"
+	writeTestContents(t, dir, map[string]string{"marks.yaml": content})
+
+	m := NewDirMarks(Config{ConfigDir: dir})
+	if got, want := m.Letters(), []rune{'A'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("letters = %q, want %q", got, want)
+	}
+	if got, want := m.marks['A'], (DirMark{Dir: "/tmp", Entry: "/tmp/a"}); got != want {
+		t.Errorf("mark A = %+v, want %+v", got, want)
+	}
+}
+
+func TestDirMarksLetters(t *testing.T) {
+	m := NewDirMarks(Config{})
+	for _, r := range "BzAab" {
+		if err := m.Set(r, DirMark{Dir: "/"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// lowercase first
+	if got, want := string(m.Letters()), "abzAB"; got != want {
+		t.Errorf("letters = %q, want %q", got, want)
+	}
+
+	for _, r := range "1'é" {
+		err := m.Set(r, DirMark{Dir: "/"})
+		if err == nil || !strings.HasPrefix(err.Error(), ErrInvalidMark.Error()) {
+			t.Errorf("Set(%c) err = %v, want %v", r, err, ErrInvalidMark)
+		}
+	}
+	if got := len(m.Letters()); got != 5 {
+		t.Errorf("len(letters) = %d, want 5", got)
+	}
+}
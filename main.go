@@ -32,6 +32,20 @@ func printError(err error) {
 	fmt.Fprintln(os.Stderr, err)
 }
 
+// readConfig read the config file, keys not in the file keep the default values
+func readConfig(file string) (gui.Config, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return gui.Config{}, err
+	}
+
+	config := gui.DefaultConfig()
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return gui.Config{}, err
+	}
+	return config, nil
+}
+
 func initConfig() gui.Config {
 	var config gui.Config
 
@@ -45,15 +59,10 @@ func initConfig() gui.Config {
 
 		configFile := filepath.Join(configDir, "config.yaml")
 		if system.IsExist(configFile) {
-			b, err := ioutil.ReadFile(configFile)
+			config, err = readConfig(configFile)
 			if err != nil {
 				printError(err)
 				config = gui.DefaultConfig()
-			} else {
-				if err := yaml.Unmarshal(b, &config); err != nil {
-					printError(err)
-					config = gui.DefaultConfig()
-				}
 			}
 		} else {
 			config = gui.DefaultConfig()
@@ -62,6 +71,11 @@ func initConfig() gui.Config {
 		config.ConfigFile = configFile
 	}
 
+	// remembered state is preferred to config, and flags are preferred to both
+	if err := gui.LoadState(&config); err != nil {
+		printError(err)
+	}
+
 	// override config when use flags
 	if *enablePreview {
 		config.Preview.Enable = *enablePreview
@@ -93,7 +107,10 @@ func initConfig() gui.Config {
 		system.OpenCmd = config.OpenCmd
 	}
 
-	config.ShowHidden = *showHidden
+	if *showHidden {
+		config.ShowHidden = *showHidden
+	}
+
 	return config
 }
 
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestReadConfig(t *testing.T) {
+	file, remove := writeConfig(t, "preview:\n  enable: true\n")
+	defer remove()
+
+	config, err := readConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Preview.Enable {
+		t.Error("Preview.Enable = false, want true")
+	}
+	// keys not in the file keep the default values
+	if !config.Git.Enable {
+		t.Error("Git.Enable = false, want true")
+	}
+	if !config.Ignore.Gitignore {
+		t.Error("Ignore.Gitignore = false, want true")
+	}
+	if !config.FollowSymlinks {
+		t.Error("FollowSymlinks = false, want true")
+	}
+	if config.Preview.Colorscheme != "monokai" {
+		t.Errorf("Preview.Colorscheme = %q, want %q", config.Preview.Colorscheme, "monokai")
+	}
+}
+
+func TestReadConfigOverride(t *testing.T) {
+	file, remove := writeConfig(t, "git:\n  enable: false\n")
+	defer remove()
+
+	config, err := readConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Git.Enable {
+		t.Error("Git.Enable = true, want false")
+	}
+}
+
+func TestReadConfigInvalid(t *testing.T) {
+	file, remove := writeConfig(t, "git: [\n")
+	defer remove()
+
+	if _, err := readConfig(file); err == nil {
+		t.Error("err = nil, want a parse error")
+	}
+}
@@ -0,0 +1,572 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Scheme is the prefix of s3 path. e.g. s3://bucket/prefix
+const Scheme = "s3://"
+
+var (
+	ErrNoBucket    = errors.New("no bucket name")
+	ErrNoObjectKey = errors.New("no object key")
+)
+
+// Config s3 client config.
+// empty fields are filled from the standard AWS environment variables.
+type Config struct {
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// Object s3 object or common prefix
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	IsPrefix     bool
+}
+
+// Client s3 client
+type Client struct {
+	config Config
+	http   *http.Client
+}
+
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// New create new s3 client
+func New(config Config) *Client {
+	if config.Endpoint == "" {
+		config.Endpoint = getenv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	}
+	if config.Region == "" {
+		config.Region = getenv("AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.AccessKey == "" {
+		config.AccessKey = getenv("AWS_ACCESS_KEY_ID")
+	}
+	if config.SecretKey == "" {
+		config.SecretKey = getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if config.SessionToken == "" {
+		config.SessionToken = getenv("AWS_SESSION_TOKEN")
+	}
+
+	return &Client{
+		config: config,
+		http:   &http.Client{},
+	}
+}
+
+// IsPath return true if path is s3 path
+func IsPath(path string) bool {
+	return strings.HasPrefix(path, Scheme)
+}
+
+// SplitPath split s3 path to bucket and key
+func SplitPath(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, Scheme)
+	i := strings.Index(path, "/")
+	if i == -1 {
+		return path, ""
+	}
+	return path[:i], strings.Trim(path[i+1:], "/")
+}
+
+// Join join s3 dir path and name
+func Join(dir, name string) string {
+	if dir == Scheme {
+		return Scheme + name
+	}
+	return strings.TrimSuffix(dir, "/") + "/" + name
+}
+
+// joinKey join the key prefix and the name, the empty prefix is the top of the bucket
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// Parent return parent s3 path
+func Parent(path string) string {
+	bucket, key := SplitPath(path)
+	if key == "" {
+		return Scheme
+	}
+
+	i := strings.LastIndex(key, "/")
+	if i == -1 {
+		return Scheme + bucket
+	}
+	return Scheme + bucket + "/" + key[:i]
+}
+
+type apiError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (c *Client) url(bucket, key string, query url.Values) *url.URL {
+	endpoint := c.config.Endpoint
+	virtualHost := false
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.config.Region)
+		virtualHost = bucket != ""
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		log.Println(err)
+		u = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	path := strings.TrimSuffix(u.Path, "/") + "/"
+	if virtualHost {
+		u.Host = bucket + "." + u.Host
+		path += key
+	} else if bucket != "" {
+		path += bucket
+		if key != "" {
+			path += "/" + key
+		}
+	}
+
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return u
+}
+
+func (c *Client) do(method, bucket, key string, query url.Values, header http.Header,
+	body io.Reader, size int64) (*http.Response, error) {
+
+	req, err := http.NewRequest(method, c.url(bucket, key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	payloadHash := emptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+	c.sign(req, payloadHash, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		var e apiError
+		if err := xml.Unmarshal(b, &e); err != nil || e.Code == "" {
+			return nil, fmt.Errorf("%s %s: %s", method, Scheme+bucket+"/"+key, resp.Status)
+		}
+		return nil, fmt.Errorf("%s: %s", e.Code, e.Message)
+	}
+
+	return resp, nil
+}
+
+// ListBuckets list all buckets as prefixes
+func (c *Client) ListBuckets() ([]Object, error) {
+	resp, err := c.do(http.MethodGet, "", "", nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Buckets []struct {
+			Name         string    `xml:"Name"`
+			CreationDate time.Time `xml:"CreationDate"`
+		} `xml:"Buckets>Bucket"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var objects []Object
+	for _, b := range result.Buckets {
+		objects = append(objects, Object{
+			Key:          b.Name,
+			LastModified: b.CreationDate,
+			IsPrefix:     true,
+		})
+	}
+	return objects, nil
+}
+
+func (c *Client) list(bucket, prefix, delimiter string) ([]Object, error) {
+	var objects []Object
+	var token string
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(http.MethodGet, bucket, "", query, nil, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			CommonPrefixes []struct {
+				Prefix string `xml:"Prefix"`
+			} `xml:"CommonPrefixes"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range result.CommonPrefixes {
+			objects = append(objects, Object{
+				Key:      strings.TrimSuffix(p.Prefix, "/"),
+				IsPrefix: true,
+			})
+		}
+		for _, o := range result.Contents {
+			// skip directory marker
+			if o.Key == prefix || (delimiter != "" && strings.HasSuffix(o.Key, delimiter)) {
+				continue
+			}
+			objects = append(objects, Object{
+				Key:          o.Key,
+				Size:         o.Size,
+				LastModified: o.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+// List list objects and common prefixes under the path.
+// if path has no bucket, list buckets.
+func (c *Client) List(path string) ([]Object, error) {
+	bucket, key := SplitPath(path)
+	if bucket == "" {
+		return c.ListBuckets()
+	}
+
+	prefix := key
+	if prefix != "" {
+		prefix += "/"
+	}
+	return c.list(bucket, prefix, "/")
+}
+
+// Stat get object metadata
+func (c *Client) Stat(path string) (Object, error) {
+	bucket, key := SplitPath(path)
+	if key == "" {
+		return Object{Key: bucket, IsPrefix: true}, nil
+	}
+
+	resp, err := c.do(http.MethodHead, bucket, key, nil, nil, nil, 0)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{
+		Key:          key,
+		Size:         resp.ContentLength,
+		LastModified: modified,
+	}, nil
+}
+
+// Read read the object. if length is greater than 0, read only the range.
+func (c *Client) Read(path string, offset, length int64) ([]byte, error) {
+	bucket, key := SplitPath(path)
+	if key == "" {
+		return nil, ErrNoObjectKey
+	}
+
+	header := http.Header{}
+	if length > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+
+	resp, err := c.do(http.MethodGet, bucket, key, nil, header, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (c *Client) put(bucket, key string, body io.Reader, size int64) error {
+	if bucket == "" {
+		return ErrNoBucket
+	}
+	if key == "" || key == "/" {
+		return ErrNoObjectKey
+	}
+
+	if body == nil {
+		body = bytes.NewReader(nil)
+	}
+
+	resp, err := c.do(http.MethodPut, bucket, key, nil, nil, body, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Write put the object
+func (c *Client) Write(path string, body io.Reader, size int64) error {
+	bucket, key := SplitPath(path)
+	return c.put(bucket, key, body, size)
+}
+
+// MakeDir put the directory marker object
+func (c *Client) MakeDir(path string) error {
+	bucket, key := SplitPath(path)
+	return c.put(bucket, key+"/", nil, 0)
+}
+
+// Remove remove the object, or all objects under the prefix if isDir is true
+func (c *Client) Remove(path string, isDir bool) error {
+	bucket, key := SplitPath(path)
+	if key == "" {
+		return ErrNoObjectKey
+	}
+
+	if !isDir {
+		resp, err := c.do(http.MethodDelete, bucket, key, nil, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	objects, err := c.list(bucket, key+"/", "")
+	if err != nil {
+		return err
+	}
+	objects = append(objects, Object{Key: key + "/"})
+
+	for _, o := range objects {
+		resp, err := c.do(http.MethodDelete, bucket, o.Key, nil, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (c *Client) download(bucket, key, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodGet, bucket, key, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+// Download download the object, or all objects under the prefix if isDir is true
+func (c *Client) Download(path, target string, isDir bool) error {
+	bucket, key := SplitPath(path)
+	if bucket == "" {
+		return ErrNoBucket
+	}
+
+	if !isDir {
+		return c.download(bucket, key, target)
+	}
+
+	prefix := key
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := c.list(bucket, prefix, "")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(target, 0777); err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		name := strings.TrimPrefix(o.Key, prefix)
+		local := filepath.Join(target, filepath.FromSlash(name))
+		if strings.HasSuffix(o.Key, "/") {
+			if err := os.MkdirAll(local, 0777); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.download(bucket, o.Key, local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) upload(src, target string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return c.Write(target, f, info.Size())
+}
+
+// Upload upload local file or directory to the path
+func (c *Client) Upload(src, target string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return c.upload(src, target)
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dest := target
+		if rel != "." {
+			dest = Join(target, filepath.ToSlash(rel))
+		}
+
+		if info.IsDir() {
+			return c.MakeDir(dest)
+		}
+		return c.upload(path, dest)
+	})
+}
+
+func (c *Client) copyObject(srcBucket, srcKey, bucket, key string) error {
+	if key == "" {
+		return ErrNoObjectKey
+	}
+
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", uriEncode("/"+srcBucket+"/"+srcKey, false))
+
+	resp, err := c.do(http.MethodPut, bucket, key, nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Copy copy the object, or all objects under the prefix if isDir is true
+func (c *Client) Copy(src, target string, isDir bool) error {
+	bucket, key := SplitPath(src)
+	if bucket == "" {
+		return ErrNoBucket
+	}
+	targetBucket, targetKey := SplitPath(target)
+
+	if !isDir {
+		return c.copyObject(bucket, key, targetBucket, targetKey)
+	}
+
+	prefix := key
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := c.list(bucket, prefix, "")
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		key := joinKey(targetKey, strings.TrimPrefix(o.Key, prefix))
+		if err := c.copyObject(bucket, o.Key, targetBucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const listPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Prefix>dir/</Prefix>
+  <IsTruncated>true</IsTruncated>
+  <NextContinuationToken>next</NextContinuationToken>
+  <Contents>
+    <Key>dir/</Key>
+    <Size>0</Size>
+    <LastModified>2021-03-01T00:00:00.000Z</LastModified>
+  </Contents>
+  <Contents>
+    <Key>dir/a.txt</Key>
+    <Size>10</Size>
+    <LastModified>2021-03-01T12:34:56.000Z</LastModified>
+  </Contents>
+  <CommonPrefixes>
+    <Prefix>dir/sub/</Prefix>
+  </CommonPrefixes>
+</ListBucketResult>`
+
+const listPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Prefix>dir/</Prefix>
+  <IsTruncated>false</IsTruncated>
+  <Contents>
+    <Key>dir/b.txt</Key>
+    <Size>20</Size>
+    <LastModified>2021-03-02T00:00:00.000Z</LastModified>
+  </Contents>
+</ListBucketResult>`
+
+func TestList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/bucket" || q.Get("list-type") != "2" || q.Get("prefix") != "dir/" || q.Get("delimiter") != "/" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if q.Get("continuation-token") == "next" {
+			fmt.Fprint(w, listPage2)
+			return
+		}
+		fmt.Fprint(w, listPage1)
+	}))
+	defer server.Close()
+
+	c := New(Config{Endpoint: server.URL, AccessKey: "key", SecretKey: "secret"})
+	got, err := c.List("s3://bucket/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Object{
+		{Key: "dir/sub", IsPrefix: true},
+		{Key: "dir/a.txt", Size: 10, LastModified: time.Date(2021, 3, 1, 12, 34, 56, 0, time.UTC)},
+		{Key: "dir/b.txt", Size: 20, LastModified: time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	var mu sync.Mutex
+	var copies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `<ListBucketResult><Contents><Key>dir/</Key></Contents>`+
+				`<Contents><Key>dir/a.txt</Key></Contents><Contents><Key>dir/sub/</Key></Contents>`+
+				`<Contents><Key>dir/sub/b.txt</Key></Contents></ListBucketResult>`)
+		case http.MethodPut:
+			mu.Lock()
+			copies = append(copies, r.Header.Get("X-Amz-Copy-Source")+" "+r.URL.Path)
+			mu.Unlock()
+			fmt.Fprint(w, `<CopyObjectResult></CopyObjectResult>`)
+		}
+	}))
+	defer server.Close()
+	c := New(Config{Endpoint: server.URL, AccessKey: "key", SecretKey: "secret"})
+
+	tests := []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{
+			name:   "to prefix",
+			target: "s3://other/copy",
+			want: []string{
+				"/bucket/dir/a.txt /other/copy/a.txt",
+				"/bucket/dir/sub/ /other/copy/sub/",
+				"/bucket/dir/sub/b.txt /other/copy/sub/b.txt",
+			},
+		},
+		{
+			name:   "to the top of the bucket",
+			target: "s3://other",
+			want: []string{
+				"/bucket/dir/a.txt /other/a.txt",
+				"/bucket/dir/sub/ /other/sub/",
+				"/bucket/dir/sub/b.txt /other/sub/b.txt",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copies = nil
+			if err := c.Copy("s3://bucket/dir", tt.target, true); err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(copies)
+			if !reflect.DeepEqual(copies, tt.want) {
+				t.Errorf("copies = %q, want %q", copies, tt.want)
+			}
+		})
+	}
+}
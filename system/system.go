@@ -7,6 +7,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
 
 	"github.com/otiai10/copy"
 )
@@ -36,7 +39,8 @@ func RemoveFile(file string) error {
 	return nil
 }
 
-func NewFile(file string) error {
+// NewFile create the file, perm is applied regardless of umask if it is not 0
+func NewFile(file string, perm os.FileMode) error {
 	if IsExist(file) {
 		return ErrFileExists
 	}
@@ -47,6 +51,10 @@ func NewFile(file string) error {
 		return err
 	}
 	defer f.Close()
+
+	if perm != 0 {
+		return os.Chmod(file, perm)
+	}
 	return nil
 }
 
@@ -67,14 +75,185 @@ func Rename(oldpath, newpath string) error {
 	return nil
 }
 
+// IsExist return true if the file exists, including broken symlink
 func IsExist(name string) bool {
-	_, err := os.Stat(name)
+	_, err := os.Lstat(name)
 	return !os.IsNotExist(err)
 }
 
-func NewDir(dir string) error {
-	// TODO use inputed permission
-	return os.Mkdir(dir, 0777)
+// NewDir create the directory, perm is applied regardless of umask if it is not 0
+func NewDir(dir string, perm os.FileMode) error {
+	if err := os.Mkdir(dir, 0777); err != nil {
+		return err
+	}
+
+	if perm != 0 {
+		return os.Chmod(dir, perm)
+	}
+	return nil
+}
+
+// searchMode add the search bit to the mode where the read bit is set,
+// directories without it can't be read
+func searchMode(mode os.FileMode) os.FileMode {
+	return mode | (mode&0444)>>2
+}
+
+// Chmod change the mode of the file, and files in the directory if recursive is true.
+// directories keep the search bit where they are readable, and are changed after their children.
+// symlinks in the directory are not followed, but the path itself is followed if it is a symlink.
+func Chmod(path string, mode os.FileMode, recursive bool) error {
+	if !recursive {
+		return os.Chmod(path, mode)
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	return chmodTree(target, mode)
+}
+
+func chmodTree(path string, mode os.FileMode) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	// chmod follows symlinks
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	if !info.IsDir() {
+		return os.Chmod(path, mode)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := chmodTree(filepath.Join(path, name), mode); err != nil {
+			return err
+		}
+	}
+	return os.Chmod(path, searchMode(mode))
+}
+
+// lookupID get id from the name or the numeric id
+func lookupID(name string, lookup func(name string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(id)
+}
+
+// Chown change the owner and the group of the file, and files in the directory if recursive is true.
+// owner and group are names or numeric ids, empty one is not changed.
+func Chown(path, owner, group string, recursive bool) error {
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return err
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return err
+		}
+		gid = id
+	}
+
+	if !recursive {
+		return os.Lchown(path, uid, gid)
+	}
+
+	return filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(name, uid, gid)
+	})
+}
+
+// Symlink create symbolic link to the source, the link has relative path if possible
+func Symlink(source, link string) error {
+	if IsExist(link) {
+		return ErrFileExists
+	}
+
+	if rel, err := filepath.Rel(filepath.Dir(link), source); err == nil {
+		source = rel
+	}
+	return os.Symlink(source, link)
+}
+
+// Link create hard link to the source
+func Link(source, link string) error {
+	if IsExist(link) {
+		return ErrFileExists
+	}
+	return os.Link(source, link)
+}
+
+// Sync copy the source to the target, and keep the modified time of the copied files
+func Sync(src, target string) error {
+	if err := copy.Copy(src, target); err != nil {
+		return err
+	}
+
+	return filepath.Walk(src, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// symlinks are copied as symlinks
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(filepath.Join(target, rel), info.ModTime(), info.ModTime())
+	})
+}
+
+// ReplaceWithLink replace the target with a hard link to the source,
+// the target is kept if the link can't be created
+func ReplaceWithLink(source, target string) error {
+	tmp := fmt.Sprintf("%s.ff-link-%d", target, os.Getpid())
+	if err := os.Link(source, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
 }
 
 func RemoveDirAll(dir string) error {
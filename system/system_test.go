@@ -0,0 +1,147 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSearchMode(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want os.FileMode
+	}{
+		{0644, 0755},
+		{0600, 0700},
+		{0640, 0750},
+		{0000, 0000},
+		{0755, 0755},
+		{0200, 0200},
+	}
+	for _, tt := range tests {
+		if got := searchMode(tt.mode); got != tt.want {
+			t.Errorf("searchMode(%o) = %o, want %o", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestChmodRecursive(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"dir/a":       "a",
+		"dir/sub/b":   "b",
+		"dir/sub/c/d": "d",
+		"outside":     "o",
+	})
+	if err := os.Symlink(filepath.Join(root, "outside"), filepath.Join(root, "dir", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "dir"), filepath.Join(root, "dirlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the symlink to the directory is followed
+	if err := Chmod(filepath.Join(root, "dirlink"), 0644, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]os.FileMode{
+		"dir":         0755,
+		"dir/a":       0644,
+		"dir/sub":     0755,
+		"dir/sub/b":   0644,
+		"dir/sub/c":   0755,
+		"dir/sub/c/d": 0644,
+		// symlinks in the directory are not followed
+		"outside": 0600,
+	}
+	for name, mode := range want {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Errorf("%s: mode = %o, want %o", name, got, mode)
+		}
+	}
+}
+
+func TestChmodNotRecursive(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{"dir/a": "a"})
+	if err := Chmod(filepath.Join(root, "dir"), 0700, false); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(root, "dir", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("mode of the child = %o, want 0600", got)
+	}
+}
+
+func TestSync(t *testing.T) {
+	root := tempDir(t)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"src/a":     "a",
+		"src/sub/b": "b",
+	})
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, name := range []string{"src/a", "src/sub/b", "src/sub"} {
+		if err := os.Chtimes(filepath.Join(root, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Sync(filepath.Join(root, "src"), filepath.Join(root, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "sub/b", "sub"} {
+		info, err := os.Stat(filepath.Join(root, "dst", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("%s: modified time = %s, want %s", name, info.ModTime(), old)
+		}
+	}
+	b, err := ioutil.ReadFile(filepath.Join(root, "dst", "sub", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "b" {
+		t.Errorf("content = %q, want %q", b, "b")
+	}
+}